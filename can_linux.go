@@ -0,0 +1,253 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	unprivilegedPortStartPath = "/proc/sys/net/ipv4/ip_unprivileged_port_start"
+	pingGroupRangePath        = "/proc/sys/net/ipv4/ping_group_range"
+	ptraceScopePath           = "/proc/sys/kernel/yama/ptrace_scope"
+
+	// defaultUnprivilegedPortStart is used on kernels older than 4.11
+	defaultUnprivilegedPortStart = 1024
+)
+
+// The Can* functions answer whether the calling process is allowed to do an
+// operation, looking at its capabilities together with its credentials, the
+// relevant sysctls and the ownership of the target. They return the answer
+// and the reason for it. They only look at the initial user namespace rules,
+// and do not take security modules other than Yama into account.
+
+// CanBindPort returns true if the calling process can bind the TCP or UDP
+// port
+func CanBindPort(port int) (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	start := defaultUnprivilegedPortStart
+	if value, err := readSysctlInt(unprivilegedPortStartPath); err == nil {
+		start = value
+	}
+
+	return canBindPort(state, start, port)
+}
+
+func canBindPort(state ProcessState, start, port int) (bool, string) {
+	if port == 0 || port >= start {
+		return true, fmt.Sprintf("port %d is not privileged, ip_unprivileged_port_start is %d", port, start)
+	}
+	return haveFor(state, OpBindPrivilegedPort)
+}
+
+// CanOpenRawSocket returns true if the calling process can open SOCK_RAW and
+// AF_PACKET sockets
+func CanOpenRawSocket() (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+	return haveFor(state, OpRawSocket)
+}
+
+// CanICMPEcho returns true if the calling process can send ICMP echo
+// requests, either through an unprivileged ICMP socket allowed by
+// ping_group_range, or through a raw socket.
+func CanICMPEcho() (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	groups, err := syscall.Getgroups()
+	if err != nil {
+		return false, err.Error()
+	}
+
+	low, high := 1, 0
+	if content, err := os.ReadFile(pingGroupRangePath); err == nil {
+		fields := strings.Fields(string(content))
+		if len(fields) == 2 {
+			low, _ = strconv.Atoi(fields[0])
+			high, _ = strconv.Atoi(fields[1])
+		}
+	}
+
+	return canICMPEcho(state, groups, low, high)
+}
+
+func canICMPEcho(state ProcessState, groups []int, low, high int) (bool, string) {
+	// the kernel checks the effective gid, as for file access
+	for _, gid := range append([]int{state.GID.Effective}, groups...) {
+		if gid >= low && gid <= high {
+			return true, fmt.Sprintf("group %d is in ping_group_range %d %d", gid, low, high)
+		}
+	}
+	return haveFor(state, OpRawSocket)
+}
+
+// CanKill returns true if the calling process can send signals to pid
+func CanKill(pid int) (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+	target, err := ReadProcessState(pid)
+	if err != nil {
+		return false, err.Error()
+	}
+	return canKill(state, target)
+}
+
+func canKill(state, target ProcessState) (bool, string) {
+	if state.PID == target.PID {
+		return true, "the process can always signal itself"
+	}
+	for _, uid := range []int{state.UID.Real, state.UID.Effective} {
+		if uid == target.UID.Real || uid == target.UID.Saved {
+			return true, fmt.Sprintf("uid %d matches the real or saved uid of %d", uid, target.PID)
+		}
+	}
+	return haveFor(state, OpKillOtherUID)
+}
+
+// CanChown returns true if the calling process can change the owner of path
+// to uid
+func CanChown(path string, uid int) (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return false, fmt.Sprintf("%s: %s", path, err)
+	}
+
+	return canChown(state, int(stat.Uid), uid)
+}
+
+func canChown(state ProcessState, owner, uid int) (bool, string) {
+	if state.UID.FS == owner && uid == owner {
+		return true, fmt.Sprintf("the file is already owned by uid %d", uid)
+	}
+	return haveFor(state, OpChownOtherUID)
+}
+
+// CanPtrace returns true if the calling process can attach to pid with
+// ptrace, following the PTRACE_MODE_ATTACH_REALCREDS rules and the Yama
+// ptrace_scope sysctl. The dumpable flag of the target is not checked.
+func CanPtrace(pid int) (bool, string) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return false, err.Error()
+	}
+	target, err := ReadProcessState(pid)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	scope, err := readSysctlInt(ptraceScopePath)
+	if err != nil {
+		// Yama is not enabled
+		scope = 0
+	}
+
+	descendant := false
+	for ancestor := target; ancestor.PPID > 0; {
+		if ancestor.PPID == state.PID {
+			descendant = true
+			break
+		}
+		ancestor, err = ReadProcessState(ancestor.PPID)
+		if err != nil {
+			break
+		}
+	}
+
+	return canPtrace(state, target, scope, descendant)
+}
+
+func canPtrace(state, target ProcessState, scope int, descendant bool) (bool, string) {
+	if state.PID == target.PID {
+		return true, "the process can always trace itself"
+	}
+
+	traceCapable := state.Caps.Effective.Has(CAPSysPTrace)
+	switch scope {
+	case 1:
+		if !descendant && !traceCapable {
+			return false, fmt.Sprintf("%d is not a descendant and ptrace_scope is 1", target.PID)
+		}
+	case 2:
+		if !traceCapable {
+			return haveFor(state, OpPTrace)
+		}
+	case 3:
+		return false, "ptrace_scope is 3, ptrace is disabled"
+	}
+
+	sameCreds := idsAre(target.UID, state.UID.Real) && idsAre(target.GID, state.GID.Real)
+	if !sameCreds {
+		if ok, reason := haveFor(state, OpPTrace); !ok {
+			return false, fmt.Sprintf("the uids or gids of %d differ: %s", target.PID, reason)
+		}
+	}
+
+	if !target.Caps.Permitted.IsSubset(state.Caps.Permitted) && !traceCapable {
+		return false, fmt.Sprintf(
+			"%d has capabilities the process does not have (%s): %s",
+			target.PID, target.Caps.Permitted.Difference(state.Caps.Permitted), requirementReason(OpPTrace),
+		)
+	}
+
+	if !sameCreds {
+		return true, fmt.Sprintf("%s is in the effective set", CAPSysPTrace)
+	}
+	return true, fmt.Sprintf("%d has the same credentials and no extra capabilities", target.PID)
+}
+
+// haveFor checks the effective set of state against the requirement of op
+func haveFor(state ProcessState, op Operation) (bool, string) {
+	requirement, err := RequiredFor(op)
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, capability := range requirement.AnyOf {
+		if state.Caps.Effective.Has(capability) {
+			return true, fmt.Sprintf("%s is in the effective set", capability)
+		}
+	}
+	return false, requirementReason(op)
+}
+
+// requirementReason returns the reason given when the requirement of op is
+// not met
+func requirementReason(op Operation) string {
+	requirement, err := RequiredFor(op)
+	if err != nil {
+		return err.Error()
+	}
+	return "missing capability: " + requirement.String()
+}
+
+// idsAre returns true if the real, effective and saved ids are all id
+func idsAre(ids IDs, id int) bool {
+	return ids.Real == id && ids.Effective == id && ids.Saved == id
+}
+
+func readSysctlInt(path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
@@ -0,0 +1,143 @@
+//go:build linux
+
+package gocapng
+
+import "testing"
+
+func TestCanPredicates(t *testing.T) {
+	user := ProcessState{
+		PID: 100,
+		UID: IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000},
+		GID: IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000},
+	}
+	other := user
+	other.PID = 200
+	other.UID = IDs{Real: 1001, Effective: 1001, Saved: 1001, FS: 1001}
+
+	capable := func(state ProcessState, caps ...Capability) ProcessState {
+		state.Caps.Permitted = NewCapSet(caps...)
+		state.Caps.Effective = NewCapSet(caps...)
+		return state
+	}
+
+	toCheck := []struct {
+		name     string
+		allowed  func() (bool, string)
+		expected bool
+	}{
+		{
+			name:     "bind 8080",
+			allowed:  func() (bool, string) { return canBindPort(user, 1024, 8080) },
+			expected: true,
+		},
+		{
+			name:    "bind 80",
+			allowed: func() (bool, string) { return canBindPort(user, 1024, 80) },
+		},
+		{
+			name:     "bind 80 with ip_unprivileged_port_start 0",
+			allowed:  func() (bool, string) { return canBindPort(user, 0, 80) },
+			expected: true,
+		},
+		{
+			name: "bind 80 with net_bind_service",
+			allowed: func() (bool, string) {
+				return canBindPort(capable(user, CAPNetBindService), 1024, 80)
+			},
+			expected: true,
+		},
+		{
+			name:    "icmp echo with the default ping_group_range",
+			allowed: func() (bool, string) { return canICMPEcho(user, nil, 1, 0) },
+		},
+		{
+			name:     "icmp echo with a supplementary group in range",
+			allowed:  func() (bool, string) { return canICMPEcho(user, []int{5}, 0, 10) },
+			expected: true,
+		},
+		{
+			name: "icmp echo with an effective gid in range",
+			allowed: func() (bool, string) {
+				state := user
+				state.GID.Effective = 5
+				return canICMPEcho(state, nil, 0, 10)
+			},
+			expected: true,
+		},
+		{
+			name: "icmp echo with only the real gid in range",
+			allowed: func() (bool, string) {
+				state := user
+				state.GID.Real = 5
+				return canICMPEcho(state, nil, 0, 10)
+			},
+		},
+		{
+			name:    "kill other uid",
+			allowed: func() (bool, string) { return canKill(user, other) },
+		},
+		{
+			name:     "kill other uid with kill",
+			allowed:  func() (bool, string) { return canKill(capable(user, CAPKill), other) },
+			expected: true,
+		},
+		{
+			name:    "chown to other uid",
+			allowed: func() (bool, string) { return canChown(user, 1000, 1001) },
+		},
+		{
+			name:     "chown to own uid",
+			allowed:  func() (bool, string) { return canChown(user, 1000, 1000) },
+			expected: true,
+		},
+		{
+			name: "ptrace same uid",
+			allowed: func() (bool, string) {
+				target := user
+				target.PID = 300
+				return canPtrace(user, target, 0, false)
+			},
+			expected: true,
+		},
+		{
+			name: "ptrace same uid with more capabilities",
+			allowed: func() (bool, string) {
+				target := capable(user, CAPNetRaw)
+				target.PID = 300
+				return canPtrace(user, target, 0, false)
+			},
+		},
+		{
+			name: "ptrace non descendant with ptrace_scope 1",
+			allowed: func() (bool, string) {
+				target := user
+				target.PID = 300
+				return canPtrace(user, target, 1, false)
+			},
+		},
+		{
+			name:     "ptrace other uid with sys_ptrace",
+			allowed:  func() (bool, string) { return canPtrace(capable(user, CAPSysPTrace), other, 1, false) },
+			expected: true,
+		},
+	}
+
+	for _, check := range toCheck {
+		allowed, reason := check.allowed()
+		if allowed != check.expected || reason == "" {
+			t.Errorf(
+				"'%s' expected to be %t but have %t instead (%s)",
+				check.name, check.expected, allowed, reason,
+			)
+		}
+	}
+}
+
+func TestCanSelf(t *testing.T) {
+	if allowed, reason := CanKill(0); !allowed {
+		t.Errorf("Expected to be able to signal itself: %s", reason)
+	}
+	if allowed, reason := CanBindPort(0); !allowed {
+		t.Errorf("Expected to be able to bind an ephemeral port: %s", reason)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build linux && !cgo
+
+// Package capdrop reduces the whole process to a capability policy before
+// main runs, so that no goroutine ever runs with more privileges than the
+// policy allows.
+//
+// Importing the package for its side effect enforces the policy from one of
+// the following sources, in this order:
+//
+//   - a JSON policy set at link time:
+//     go build -ldflags "-X 'github.com/ik5/gocapng/capdrop.embedded=$(cat policy.json)'"
+//   - the policy file named by the GOCAPNG_POLICY environment variable, which
+//     is refused when the program runs set-id or with file capabilities.
+//
+// To enforce a policy embedded with go:embed instead, use the embedded
+// subpackage.
+//
+// The policy is applied to every thread with gocapng.EnforcePolicyAllThreads,
+// which needs a program built with CGO_ENABLED=0: the package does not build
+// with cgo, rather than leaving the process privileged. The package fails
+// closed: when no policy is given, or when it cannot be read or fully
+// enforced, the process exits with status 1 before main runs. What was
+// dropped is logged to the standard error.
+package capdrop
+
+import (
+	"log"
+	"os"
+
+	capdropembedded "github.com/ik5/gocapng/capdrop/embedded"
+	"github.com/ik5/gocapng/capdrop/internal/source"
+)
+
+// EnvPolicy is the environment variable naming the policy file
+const EnvPolicy = source.EnvPolicy
+
+// embedded is set at link time with -X
+var embedded string
+
+func init() {
+	data, format, err := source.Policy(embedded, os.Getenv(EnvPolicy), source.SecureExecution())
+	if err != nil {
+		capdropembedded.Fail(err)
+	}
+	capdropembedded.MustEnforce(data, format)
+}
+
+// SetLogger replaces the logger used to report what was dropped. The policy
+// given at link time or by GOCAPNG_POLICY is enforced before SetLogger can be
+// called, and is always logged to the standard error.
+func SetLogger(l *log.Logger) {
+	capdropembedded.SetLogger(l)
+}
@@ -0,0 +1,124 @@
+//go:build linux && !cgo
+
+// Package embedded reduces the whole process to a capability policy compiled
+// into the program, usually with go:embed.
+//
+// Unlike capdrop, importing the package changes nothing. The policy must be
+// enforced by a package that is initialized before the others, as the main
+// package is initialized last:
+//
+//	package privileges
+//
+//	import (
+//		_ "embed"
+//
+//		"github.com/ik5/gocapng/capdrop/embedded"
+//	)
+//
+//	//go:embed policy.json
+//	var policy []byte
+//
+//	func init() {
+//		embedded.MustEnforce(policy, "json")
+//	}
+//
+// and blank import it from the main package. The init functions of the
+// packages initialized before it still run with every privilege.
+//
+// The policy is applied to every thread with gocapng.EnforcePolicyAllThreads,
+// which needs a program built with CGO_ENABLED=0: the package does not build
+// with cgo.
+package embedded
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/ik5/gocapng"
+)
+
+var (
+	loggerLock sync.Mutex
+	logger     = log.New(os.Stderr, "capdrop: ", log.LstdFlags)
+)
+
+// SetLogger replaces the logger used to report what was dropped
+func SetLogger(l *log.Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+func getLogger() *log.Logger {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	return logger
+}
+
+// Enforce reduces every thread of the process to the policy, given in format
+// (see gocapng.ParsePolicy), and logs what was dropped
+func Enforce(data []byte, format string) error {
+	policy, err := gocapng.ParsePolicy(data, format)
+	if err != nil {
+		return err
+	}
+
+	run, err := gocapng.EnforcePolicyAllThreads(policy)
+	for _, line := range dropped(run) {
+		getLogger().Print(line)
+	}
+	return err
+}
+
+// MustEnforce is like Enforce, but exits the process with status 1 when the
+// policy cannot be read or fully enforced
+func MustEnforce(data []byte, format string) {
+	if err := Enforce(data, format); err != nil {
+		Fail(err)
+	}
+}
+
+// Fail logs err and exits the process with status 1
+func Fail(err error) {
+	getLogger().Printf("refusing to start: %s", err)
+	os.Exit(1)
+}
+
+// dropped describes what changed between the states of run
+func dropped(run gocapng.DryRun) []string {
+	var lines []string
+
+	before, after := run.Before.Caps, run.After.Caps
+	sets := []struct {
+		name          string
+		before, after gocapng.CapSet
+	}{
+		{"effective", before.Effective, after.Effective},
+		{"permitted", before.Permitted, after.Permitted},
+		{"inheritable", before.Inheritable, after.Inheritable},
+		{"bounding_set", before.Bounding, after.Bounding},
+		{"ambient", before.Ambient, after.Ambient},
+	}
+	for _, set := range sets {
+		if gone := set.before.Difference(set.after); !gone.IsEmpty() {
+			lines = append(lines, fmt.Sprintf("dropped %s from %s", gone, set.name))
+		}
+	}
+
+	if run.Before.UID != run.After.UID || run.Before.GID != run.After.GID {
+		lines = append(lines, fmt.Sprintf(
+			"changed uid %s and gid %s to uid %s and gid %s",
+			run.Before.UID, run.Before.GID, run.After.UID, run.After.GID,
+		))
+	}
+	if run.Before.Securebits != run.After.Securebits {
+		lines = append(lines, fmt.Sprintf("set securebits %s", run.After.Securebits))
+	}
+	if !run.Before.NoNewPrivs && run.After.NoNewPrivs {
+		lines = append(lines, "set no_new_privs")
+	}
+
+	return lines
+}
@@ -0,0 +1,49 @@
+//go:build linux && !cgo
+
+package embedded
+
+import (
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+func TestDropped(t *testing.T) {
+	root := gocapng.IDs{}
+	nobody := gocapng.IDs{Real: 65534, Effective: 65534, Saved: 65534, FS: 65534}
+	run := gocapng.DryRun{
+		Before: gocapng.ProcessState{
+			Caps: gocapng.Capabilities{
+				Effective: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPSysAdmin),
+				Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPSysAdmin),
+			},
+			UID: root,
+			GID: root,
+		},
+		After: gocapng.ProcessState{
+			Caps: gocapng.Capabilities{
+				Effective: gocapng.NewCapSet(gocapng.CAPNetRaw),
+				Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			},
+			UID:        nobody,
+			GID:        nobody,
+			NoNewPrivs: true,
+		},
+	}
+
+	expected := []string{
+		"dropped sys_admin from effective",
+		"dropped sys_admin from permitted",
+		"changed uid 0,0,0,0 and gid 0,0,0,0 to uid 65534,65534,65534,65534 and gid 65534,65534,65534,65534",
+		"set no_new_privs",
+	}
+	lines := dropped(run)
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %q, got %q", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Expected '%s', got '%s'", expected[i], lines[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+// Package source finds the policy capdrop enforces. It is kept apart from
+// capdrop, whose tests could not run otherwise, as importing capdrop without
+// a policy exits the process.
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// EnvPolicy is the environment variable naming the policy file
+const EnvPolicy = "GOCAPNG_POLICY"
+
+// atSecure is the auxiliary vector entry set for set-id and file capabilities
+// executions
+const atSecure = 23
+
+// Policy returns the policy to enforce and its format: embedded, set at link
+// time, or else the file named by env, unless the program runs in a secure
+// execution
+func Policy(embedded, env string, secure bool) ([]byte, string, error) {
+	if embedded != "" {
+		return []byte(embedded), "json", nil
+	}
+	if env == "" {
+		return nil, "", fmt.Errorf("no policy given at link time or by %s", EnvPolicy)
+	}
+	if secure {
+		return nil, "", fmt.Errorf("%s is not honoured in a secure execution", EnvPolicy)
+	}
+
+	data, err := os.ReadFile(env)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, strings.TrimPrefix(filepath.Ext(env), "."), nil
+}
+
+// SecureExecution returns true when the kernel set AT_SECURE for the program,
+// that is when it runs set-id or gained file capabilities. It fails closed
+// when the auxiliary vector cannot be read.
+func SecureExecution() bool {
+	auxv, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return true
+	}
+
+	word := int(unsafe.Sizeof(uintptr(0)))
+	for i := 0; i+2*word <= len(auxv); i += 2 * word {
+		key := *(*uintptr)(unsafe.Pointer(&auxv[i]))
+		if key == atSecure {
+			return *(*uintptr)(unsafe.Pointer(&auxv[i+word])) != 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	toCheck := []struct {
+		name     string
+		embedded string
+		env      string
+		secure   bool
+		expected string
+		format   string
+		err      bool
+	}{
+		{
+			name: "none",
+			err:  true,
+		},
+		{
+			name:     "embedded",
+			embedded: `{"version": 1, "name": "embedded"}`,
+			env:      path,
+			secure:   true,
+			expected: `{"version": 1, "name": "embedded"}`,
+			format:   "json",
+		},
+		{
+			name:     "environment",
+			env:      path,
+			expected: `{"version": 1}`,
+			format:   "json",
+		},
+		{
+			name:   "environment in secure execution",
+			env:    path,
+			secure: true,
+			err:    true,
+		},
+		{
+			name: "missing file",
+			env:  path + ".missing",
+			err:  true,
+		},
+	}
+
+	for _, check := range toCheck {
+		data, format, err := Policy(check.embedded, check.env, check.secure)
+		if (err != nil) != check.err || string(data) != check.expected || format != check.format {
+			t.Errorf(
+				"'%s' expected to return '%s' (%s) but have '%s' (%s, %v) instead",
+				check.name, check.expected, check.format, data, format, err,
+			)
+		}
+	}
+}
+
+func TestSecureExecution(t *testing.T) {
+	if SecureExecution() {
+		t.Error("Expected the test binary not to run in a secure execution")
+	}
+}
@@ -0,0 +1,506 @@
+//go:build linux
+
+// Package capngfake provides an in-memory gocapng.Manager for unit tests.
+//
+// The fake holds the state of a pretend process, and the capabilities stored
+// in a pretend libcap-ng, and models the rules of the kernel with
+// gocapng.PredictApply, gocapng.PredictChangeID and gocapng.SimulateSetUID:
+//
+//	fake := capngfake.New(capngfake.Root())
+//	fake.FailNext("Apply", gocapng.ErrSelectCapsCapsetSyscall)
+//	err := serviceUnderTest(fake)
+package capngfake
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ik5/gocapng"
+)
+
+// full holds every capability known to gocapng. The fake uses it instead of
+// gocapng.FullCapSet, so that it behaves the same whatever the kernel running
+// the tests supports.
+const full = gocapng.CapSet(1<<(gocapng.CAPLastCap+1) - 1)
+
+// Fake is an in-memory gocapng.Manager
+type Fake struct {
+	mu sync.Mutex
+
+	// Self is the state of the process calling the fake
+	Self gocapng.ProcessState
+	// Processes holds the state of the other processes, by pid
+	Processes map[int]gocapng.ProcessState
+	// Files holds the file capabilities, by file name (os.File.Name)
+	Files map[string]gocapng.FileCaps
+
+	pending  gocapng.Capabilities
+	pid      int
+	rootID   int
+	failures map[string][]error
+}
+
+var _ gocapng.Manager = (*Fake)(nil)
+
+// New returns a fake for a process in the self state, with nothing stored
+// yet, like a freshly initialized libcap-ng
+func New(self gocapng.ProcessState) *Fake {
+	return &Fake{
+		Self:      self,
+		Processes: map[int]gocapng.ProcessState{},
+		Files:     map[string]gocapng.FileCaps{},
+		rootID:    gocapng.UnsetRootID,
+		failures:  map[string][]error{},
+	}
+}
+
+// Root returns the state of a process running as root with every capability
+func Root() gocapng.ProcessState {
+	return gocapng.ProcessState{
+		PID: os.Getpid(),
+		Caps: gocapng.Capabilities{
+			Effective: full,
+			Permitted: full,
+			Bounding:  full,
+		},
+	}
+}
+
+// User returns the state of a process running as uid and gid without
+// capabilities
+func User(uid, gid int) gocapng.ProcessState {
+	return gocapng.ProcessState{
+		PID:  os.Getpid(),
+		Caps: gocapng.Capabilities{Bounding: full},
+		UID:  gocapng.IDs{Real: uid, Effective: uid, Saved: uid, FS: uid},
+		GID:  gocapng.IDs{Real: gid, Effective: gid, Saved: gid, FS: gid},
+	}
+}
+
+// FailNext makes the next call of method (for example "Apply") fail with err,
+// regardless of the state. Methods returning a bool return false, and the
+// ones returning a Result return ResultFail. Several failures are consumed in
+// order.
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], err)
+}
+
+// Pending returns the capabilities stored in the fake libcap-ng
+func (f *Fake) Pending() gocapng.Capabilities {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending
+}
+
+// failure pops the next injected failure of method, f.mu must be held
+func (f *Fake) failure(method string) error {
+	queue := f.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+	f.failures[method] = queue[1:]
+	return queue[0]
+}
+
+// types returns the types of the sets in set
+func types(set gocapng.Select) []gocapng.Type {
+	var result []gocapng.Type
+	if set&gocapng.SelectCaps != 0 {
+		result = append(result, gocapng.TypeEffective, gocapng.TypePermitted, gocapng.TypeInheritable)
+	}
+	if set&gocapng.SelectBounds != 0 {
+		result = append(result, gocapng.TypeBoundingSet)
+	}
+	if set&gocapng.SelectAmbient != 0 {
+		result = append(result, gocapng.TypeAmbient)
+	}
+	return result
+}
+
+// Clear empties the selected stored sets
+func (f *Fake) Clear(set gocapng.Select) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range types(set) {
+		f.pending.Set(t, 0)
+	}
+}
+
+// Fill fills the selected stored sets with every capability
+func (f *Fake) Fill(set gocapng.Select) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range types(set) {
+		f.pending.Set(t, full)
+	}
+}
+
+// SetPID selects the process GetCapsProcess reads, 0 for Self
+func (f *Fake) SetPID(pid int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pid = pid
+}
+
+// GetCapsProcess stores the capabilities of the selected process
+func (f *Fake) GetCapsProcess() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("GetCapsProcess") != nil {
+		return false
+	}
+
+	if f.pid == 0 || f.pid == f.Self.PID {
+		f.pending = f.Self.Caps
+		return true
+	}
+	state, ok := f.Processes[f.pid]
+	if !ok {
+		return false
+	}
+	f.pending = state.Caps
+	return true
+}
+
+// Update adds or drops capability from the stored sets or'ed into t
+func (f *Fake) Update(action gocapng.Act, t gocapng.Type, capability gocapng.Capability) bool {
+	return f.update("Update", action, t, capability)
+}
+
+// Updatev adds or drops capabilities from the stored sets or'ed into t
+func (f *Fake) Updatev(action gocapng.Act, t gocapng.Type, capability ...gocapng.Capability) bool {
+	return f.update("Updatev", action, t, capability...)
+}
+
+func (f *Fake) update(method string, action gocapng.Act, t gocapng.Type, capability ...gocapng.Capability) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure(method) != nil || len(capability) == 0 {
+		return false
+	}
+
+	caps := gocapng.NewCapSet(capability...)
+	for _, c := range capability {
+		// NewCapSet ignores capabilities above 63
+		if !full.Has(c) {
+			return false
+		}
+	}
+	for _, set := range []gocapng.Type{
+		gocapng.TypeEffective, gocapng.TypePermitted, gocapng.TypeInheritable,
+		gocapng.TypeBoundingSet, gocapng.TypeAmbient,
+	} {
+		if t&set == 0 {
+			continue
+		}
+		switch action {
+		case gocapng.ActAdd:
+			f.pending.Set(set, f.pending.Get(set).Union(caps))
+		case gocapng.ActDrop:
+			f.pending.Set(set, f.pending.Get(set).Difference(caps))
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Apply transfers the selected stored sets to Self, following the kernel
+// rules
+func (f *Fake) Apply(set gocapng.Select) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("Apply"); err != nil {
+		return err
+	}
+
+	run := gocapng.PredictApply(f.Self, f.pending, set)
+	f.Self = run.After
+	if len(run.Errors) > 0 {
+		return run.Errors[0]
+	}
+	return nil
+}
+
+// Lock sets and locks the noroot, no_setuid_fixup and no_cap_ambient_raise
+// securebits of Self
+func (f *Fake) Lock() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("Lock") != nil || !f.Self.Caps.Effective.Has(gocapng.CAPSetPCap) {
+		return false
+	}
+
+	f.Self.Securebits |= gocapng.SecureNoRoot | gocapng.SecureNoRootLocked |
+		gocapng.SecureNoSetUIDFixup | gocapng.SecureNoSetUIDFixupLocked |
+		gocapng.SecureNoCapAmbientRaise | gocapng.SecureNoCapAmbientRaiseLocked
+	return true
+}
+
+// ChangeID changes the ids of Self, keeping the stored capabilities,
+// following the kernel rules
+func (f *Fake) ChangeID(uid, gid int, flag gocapng.Flags) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ChangeID"); err != nil {
+		return err
+	}
+
+	run := gocapng.PredictChangeID(f.Self, f.pending, uid, gid, flag)
+	if len(run.Errors) > 0 {
+		return run.Errors[0]
+	}
+	f.Self = run.After
+	return nil
+}
+
+// GetRootID returns the root id used by ApplyCapsFD
+func (f *Fake) GetRootID() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rootID
+}
+
+// SetRootID sets the root id used by ApplyCapsFD
+func (f *Fake) SetRootID(rootID int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("SetRootID") != nil {
+		return false
+	}
+	f.rootID = rootID
+	return true
+}
+
+// GetCapsFD stores the capabilities of fd from Files
+func (f *Fake) GetCapsFD(fd os.File) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("GetCapsFD") != nil {
+		return false
+	}
+
+	file, ok := f.Files[fd.Name()]
+	if !ok {
+		return false
+	}
+	f.pending.Permitted = file.Permitted
+	f.pending.Inheritable = file.Inheritable
+	f.pending.Effective = 0
+	if file.Effective {
+		f.pending.Effective = file.Permitted
+	}
+	f.rootID = file.RootID
+	return true
+}
+
+// ApplyCapsFD stores the permitted and inheritable stored sets as the file
+// capabilities of fd in Files. The file effective bit is set when the stored
+// effective set is not empty.
+func (f *Fake) ApplyCapsFD(fd os.File) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ApplyCapsFD"); err != nil {
+		return err
+	}
+	if info, err := fd.Stat(); err == nil && !info.Mode().IsRegular() {
+		return gocapng.ErrFDIsNotRegularFile
+	}
+
+	file := gocapng.FileCaps{
+		Version:     2,
+		Permitted:   f.pending.Permitted,
+		Inheritable: f.pending.Inheritable,
+		Effective:   !f.pending.Effective.IsEmpty(),
+		RootID:      gocapng.UnsetRootID,
+	}
+	if f.rootID != gocapng.UnsetRootID {
+		file.Version, file.RootID = 3, f.rootID
+	}
+	f.Files[fd.Name()] = file
+	return nil
+}
+
+// result classifies a set the way libcap-ng does
+func result(set gocapng.CapSet) gocapng.Result {
+	switch {
+	case set.IsEmpty():
+		return gocapng.ResultNone
+	case full.IsSubset(set):
+		return gocapng.ResultFull
+	default:
+		return gocapng.ResultPartial
+	}
+}
+
+// HaveCapabilities classifies the stored effective, bounding and ambient sets
+// selected by set
+func (f *Fake) HaveCapabilities(set gocapng.Select) gocapng.Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("HaveCapabilities") != nil {
+		return gocapng.ResultFail
+	}
+
+	var results []gocapng.Result
+	if set&gocapng.SelectCaps != 0 {
+		results = append(results, result(f.pending.Effective))
+	}
+	if set&gocapng.SelectBounds != 0 {
+		results = append(results, result(f.pending.Bounding))
+	}
+	if set&gocapng.SelectAmbient != 0 {
+		results = append(results, result(f.pending.Ambient))
+	}
+	if len(results) == 0 {
+		return gocapng.ResultFail
+	}
+
+	for _, r := range results[1:] {
+		if r != results[0] {
+			return gocapng.ResultPartial
+		}
+	}
+	return results[0]
+}
+
+// HavePermittedCapabilities classifies the stored permitted set
+func (f *Fake) HavePermittedCapabilities() gocapng.Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failure("HavePermittedCapabilities") != nil {
+		return gocapng.ResultFail
+	}
+	return result(f.pending.Permitted)
+}
+
+// HaveCapability returns true if capability is in the stored set which
+func (f *Fake) HaveCapability(which gocapng.Type, capability gocapng.Capability) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending.Get(which).Has(capability)
+}
+
+// PrintCapsNumberic returns the stored sets selected by set in the layout of
+// capng_print_caps_numeric, or prints them to os.Stdout
+func (f *Fake) PrintCapsNumberic(where gocapng.Print, set gocapng.Select) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var b strings.Builder
+	_ = gocapng.WriteCapabilities(&b, f.pending, set, gocapng.FormatNumeric)
+	return output(where, b.String())
+}
+
+// PrintCapsText returns the names of the stored set which in the layout of
+// capng_print_caps_text, or prints them to os.Stdout
+func (f *Fake) PrintCapsText(where gocapng.Print, which gocapng.Type) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set := f.pending.Get(which)
+	if set.IsEmpty() {
+		return output(where, "none")
+	}
+	names := make([]string, 0, set.Len())
+	for _, capability := range set.List() {
+		names = append(names, capability.String())
+	}
+	return output(where, strings.Join(names, ", "))
+}
+
+// WriteCaps writes the stored sets of set to w in the given layout
+func (f *Fake) WriteCaps(w io.Writer, set gocapng.Select, format gocapng.Format) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return gocapng.WriteCapabilities(w, f.pending, set, format)
+}
+
+func output(where gocapng.Print, text string) string {
+	if where == gocapng.PrintStdOut {
+		fmt.Fprint(os.Stdout, text)
+		return ""
+	}
+	return text
+}
+
+// NameToCapability returns the capability named name
+func (f *Fake) NameToCapability(name string) (gocapng.Capability, error) {
+	return gocapng.ParseCapability(name)
+}
+
+// CapabilityToName returns the name of capability, or an empty string for
+// unknown capabilities
+func (f *Fake) CapabilityToName(capability gocapng.Capability) string {
+	if !full.Has(capability) {
+		return ""
+	}
+	return capability.String()
+}
+
+// DryRunApply returns what Apply would do, without doing it
+func (f *Fake) DryRunApply(set gocapng.Select) (gocapng.DryRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return gocapng.PredictApply(f.Self, f.pending, set), nil
+}
+
+// DryRunChangeID returns what ChangeID would do, without doing it
+func (f *Fake) DryRunChangeID(uid, gid int, flag gocapng.Flags) (gocapng.DryRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return gocapng.PredictChangeID(f.Self, f.pending, uid, gid, flag), nil
+}
+
+// EnforcePolicyCallingThread reduces Self to the policy, refusing like the real one when
+// Self lacks something the policy requires
+func (f *Fake) EnforcePolicyCallingThread(p *gocapng.Policy) (gocapng.DryRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	run := gocapng.DryRun{
+		Operation: fmt.Sprintf("EnforcePolicyCallingThread(%s)", p.Name),
+		Before:    f.Self,
+		After:     f.Self,
+	}
+	if err := f.failure("EnforcePolicyCallingThread"); err != nil {
+		return run, err
+	}
+
+	target, err := p.Resolve()
+	if err != nil {
+		return run, err
+	}
+	if err := target.Check(f.Self); err != nil {
+		return run, err
+	}
+
+	after := f.Self
+	bounding := after.Caps.Bounding
+	if !target.KeepBounding {
+		bounding = target.Caps.Bounding
+	}
+	after.Caps = target.Caps
+	after.Caps.Bounding = bounding
+	if target.UID != -1 {
+		after.UID = gocapng.IDs{Real: target.UID, Effective: target.UID, Saved: target.UID, FS: target.UID}
+	}
+	if target.GID != -1 {
+		after.GID = gocapng.IDs{Real: target.GID, Effective: target.GID, Saved: target.GID, FS: target.GID}
+	}
+	if target.SetSecurebits {
+		after.Securebits = target.Securebits
+	}
+	after.NoNewPrivs = after.NoNewPrivs || target.NoNewPrivs
+
+	f.Self, run.After = after, after
+	f.pending = target.Caps
+	f.pending.Bounding = bounding
+	return run, nil
+}
@@ -0,0 +1,164 @@
+//go:build linux
+
+package capngfake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+func TestFakeDropPrivileges(t *testing.T) {
+	fake := New(Root())
+
+	fake.Clear(gocapng.SelectBoth)
+	if !fake.Updatev(gocapng.ActAdd, gocapng.TypeEffective|gocapng.TypePermitted, gocapng.CAPNetBindService) {
+		t.Fatal("Expected Updatev to succeed")
+	}
+	if err := fake.ChangeID(1000, 1000, gocapng.FlagsDropSuppGrp|gocapng.FlagsClearBounding); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := gocapng.NewCapSet(gocapng.CAPNetBindService)
+	if fake.Self.Caps.Effective != expected || fake.Self.Caps.Permitted != expected {
+		t.Errorf("Expected only %s, got %+v", expected, fake.Self.Caps)
+	}
+	if fake.Self.UID.Effective != 1000 || fake.Self.Caps.Bounding != 0 {
+		t.Errorf("Unexpected state after ChangeID %+v", fake.Self)
+	}
+
+	// the kernel refuses to raise capabilities that are not permitted anymore
+	fake.Fill(gocapng.SelectCaps)
+	if err := fake.Apply(gocapng.SelectCaps); !errors.Is(err, gocapng.ErrSelectCapsCapsetSyscall) {
+		t.Errorf("Expected %s, got %v", gocapng.ErrSelectCapsCapsetSyscall, err)
+	}
+	if fake.Lock() {
+		t.Error("Expected Lock to fail without setpcap")
+	}
+}
+
+func TestFakeFailNext(t *testing.T) {
+	fake := New(Root())
+	fake.FailNext("Apply", gocapng.ErrSelectBoundsCAPSetPCap)
+	fake.FailNext("Update", errors.New("injected"))
+
+	if err := fake.Apply(gocapng.SelectAll); !errors.Is(err, gocapng.ErrSelectBoundsCAPSetPCap) {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+	if err := fake.Apply(gocapng.SelectAll); err != nil {
+		t.Errorf("Expected the failure to be consumed, got %s", err)
+	}
+	if fake.Update(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPKill) {
+		t.Error("Expected Update to fail")
+	}
+	if !fake.Updatev(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPKill) {
+		t.Error("Expected Updatev to succeed")
+	}
+
+	// like libcap-ng, duplicates are accepted, unknown capabilities are not
+	if !fake.Updatev(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPNetRaw, gocapng.CAPNetRaw) {
+		t.Error("Expected Updatev to accept duplicates")
+	}
+	for _, capability := range []gocapng.Capability{gocapng.CAPLastCap + 1, 63, 64} {
+		if fake.Updatev(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPKill, capability) {
+			t.Errorf("Expected Updatev to refuse %d", capability)
+		}
+	}
+	if expected := gocapng.NewCapSet(gocapng.CAPKill, gocapng.CAPNetRaw); fake.Pending().Effective != expected {
+		t.Errorf("Expected the effective set %s, have %s", expected, fake.Pending().Effective)
+	}
+}
+
+func TestFakeQueries(t *testing.T) {
+	fake := New(User(1000, 1000))
+	fake.Processes[42] = Root()
+
+	fake.SetPID(42)
+	if !fake.GetCapsProcess() || fake.HaveCapabilities(gocapng.SelectCaps) != gocapng.ResultFull {
+		t.Error("Expected the capabilities of pid 42 to be full")
+	}
+	fake.SetPID(0)
+	if !fake.GetCapsProcess() || fake.HavePermittedCapabilities() != gocapng.ResultNone {
+		t.Error("Expected no permitted capabilities for Self")
+	}
+	if fake.HaveCapabilities(gocapng.SelectAll) != gocapng.ResultPartial {
+		t.Error("Expected a full bounding set and no capabilities to be partial")
+	}
+
+	fake.Clear(gocapng.SelectAll)
+	fake.Update(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPNetRaw)
+	fake.Update(gocapng.ActAdd, gocapng.TypeEffective, gocapng.CAPKill)
+	if text := fake.PrintCapsText(gocapng.PrintBuffer, gocapng.TypeEffective); text != "kill, net_raw" {
+		t.Errorf("Unexpected text '%s'", text)
+	}
+	numeric := fake.PrintCapsNumberic(gocapng.PrintBuffer, gocapng.SelectCaps)
+	if numeric[:36] != "Effective:   00000000, 00002020\nPerm" {
+		t.Errorf("Unexpected numeric output '%s'", numeric)
+	}
+
+	// the layout of capng_print_caps_numeric, whatever the running kernel
+	fake.Fill(gocapng.SelectAll)
+	expected := "Effective:   000001FF, FFFFFFFF\n" +
+		"Permitted:   000001FF, FFFFFFFF\n" +
+		"Inheritable: 000001FF, FFFFFFFF\n" +
+		"Bounding Set: 000001FF, FFFFFFFF\n" +
+		"Ambient Set: 000001FF, FFFFFFFF\n"
+	if numeric := fake.PrintCapsNumberic(gocapng.PrintBuffer, gocapng.SelectAll); numeric != expected {
+		t.Errorf("Expected numeric output '%s', got '%s'", expected, numeric)
+	}
+
+	if capability, err := fake.NameToCapability("net_raw"); err != nil || capability != gocapng.CAPNetRaw {
+		t.Errorf("Expected net_raw, got %s (%v)", capability, err)
+	}
+	if fake.CapabilityToName(gocapng.Capability(63)) != "" {
+		t.Error("Expected no name for an unknown capability")
+	}
+}
+
+func TestFakeFileCaps(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fake := New(Root())
+	fake.Update(gocapng.ActAdd, gocapng.TypeEffective|gocapng.TypePermitted, gocapng.CAPNetRaw)
+	if err := fake.ApplyCapsFD(*f); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	file := fake.Files[f.Name()]
+	if file.Permitted != gocapng.NewCapSet(gocapng.CAPNetRaw) || !file.Effective {
+		t.Errorf("Unexpected file capabilities %+v", file)
+	}
+
+	fake.Clear(gocapng.SelectAll)
+	if !fake.GetCapsFD(*f) || !fake.HaveCapability(gocapng.TypeEffective, gocapng.CAPNetRaw) {
+		t.Error("Expected to read back net_raw")
+	}
+}
+
+func TestFakeEnforcePolicyCallingThread(t *testing.T) {
+	fake := New(User(1000, 1000))
+	policy := &gocapng.Policy{
+		Version:   gocapng.PolicyVersion,
+		Permitted: []string{"net_raw"},
+	}
+
+	if _, err := fake.EnforcePolicyCallingThread(policy); !errors.Is(err, gocapng.ErrMissingCapabilities) {
+		t.Errorf("Expected %s, got %v", gocapng.ErrMissingCapabilities, err)
+	}
+
+	fake = New(Root())
+	run, err := fake.EnforcePolicyCallingThread(policy)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if run.After.Caps.Permitted != gocapng.NewCapSet(gocapng.CAPNetRaw) || run.After.Caps.Effective != 0 {
+		t.Errorf("Unexpected state after the policy %+v", run.After.Caps)
+	}
+}
@@ -0,0 +1,167 @@
+package gocapng
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// CapSet is a bitmap of capabilities, where bit n represents Capability(n),
+// the same layout the kernel uses for the CapEff/CapPrm/... fields of
+// /proc/<pid>/status.
+type CapSet uint64
+
+// Capabilities holds the five capabilities sets of a process
+type Capabilities struct {
+	Effective   CapSet
+	Permitted   CapSet
+	Inheritable CapSet
+	Bounding    CapSet
+	Ambient     CapSet
+}
+
+// FileCaps holds the capabilities stored in the security.capability extended
+// attribute of a file
+type FileCaps struct {
+	// Version is the revision of the attribute (2 or 3), or 0 when the file
+	// has no capabilities attribute at all.
+	Version     int
+	Permitted   CapSet
+	Inheritable CapSet
+	// Effective is the "magic" bit that raises the whole permitted set into the
+	// effective set on execve.
+	Effective bool
+	// RootID is the namespace root id of a version 3 attribute, or
+	// UnsetRootID.
+	RootID int
+}
+
+// NewCapSet returns a set holding the given capabilities
+func NewCapSet(caps ...Capability) CapSet {
+	return CapSet(0).Add(caps...)
+}
+
+// Has returns true if capability is part of the set
+func (s CapSet) Has(capability Capability) bool {
+	if capability > 63 {
+		return false
+	}
+	return s&(1<<capability) != 0
+}
+
+// Add returns a copy of the set with the given capabilities turned on.
+//
+// Add does not depend on the running kernel: capabilities it does not know
+// are kept, see FullCapSet to limit a set to the supported ones.
+func (s CapSet) Add(caps ...Capability) CapSet {
+	for _, capability := range caps {
+		if capability > 63 {
+			continue
+		}
+		s |= 1 << capability
+	}
+	return s
+}
+
+// Drop returns a copy of the set with the given capabilities turned off
+func (s CapSet) Drop(caps ...Capability) CapSet {
+	for _, capability := range caps {
+		if capability > 63 {
+			continue
+		}
+		s &^= 1 << capability
+	}
+	return s
+}
+
+// Union returns the capabilities that are either in s or in other
+func (s CapSet) Union(other CapSet) CapSet {
+	return s | other
+}
+
+// Intersect returns the capabilities that are both in s and in other
+func (s CapSet) Intersect(other CapSet) CapSet {
+	return s & other
+}
+
+// Difference returns the capabilities of s that are not in other
+func (s CapSet) Difference(other CapSet) CapSet {
+	return s &^ other
+}
+
+// IsSubset returns true if every capability of s is also in other
+func (s CapSet) IsSubset(other CapSet) bool {
+	return s&^other == 0
+}
+
+// IsEmpty returns true if the set has no capabilities
+func (s CapSet) IsEmpty() bool {
+	return s == 0
+}
+
+// Len returns the number of capabilities in the set
+func (s CapSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+// List returns the capabilities of the set in ascending order
+func (s CapSet) List() []Capability {
+	result := make([]Capability, 0, s.Len())
+	for capability := Capability(0); capability < 64; capability++ {
+		if s.Has(capability) {
+			result = append(result, capability)
+		}
+	}
+	return result
+}
+
+// String returns a comma separated list of the capabilities names, or "none"
+// for an empty set.
+func (s CapSet) String() string {
+	if s.IsEmpty() {
+		return "none"
+	}
+
+	list := s.List()
+	names := make([]string, 0, len(list))
+	for _, capability := range list {
+		names = append(names, capability.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// Get returns the set for the given Type, or an empty set when t is not a
+// single set type.
+func (c Capabilities) Get(t Type) CapSet {
+	switch t {
+	case TypeEffective:
+		return c.Effective
+	case TypePermitted:
+		return c.Permitted
+	case TypeInheritable:
+		return c.Inheritable
+	case TypeBoundingSet:
+		return c.Bounding
+	case TypeAmbient:
+		return c.Ambient
+	}
+	return 0
+}
+
+// Set replaces every set that is or'ed into t with s
+func (c *Capabilities) Set(t Type, s CapSet) {
+	if t&TypeEffective != 0 {
+		c.Effective = s
+	}
+	if t&TypePermitted != 0 {
+		c.Permitted = s
+	}
+	if t&TypeInheritable != 0 {
+		c.Inheritable = s
+	}
+	if t&TypeBoundingSet != 0 {
+		c.Bounding = s
+	}
+	if t&TypeAmbient != 0 {
+		c.Ambient = s
+	}
+}
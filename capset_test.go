@@ -0,0 +1,66 @@
+package gocapng
+
+import "testing"
+
+func TestCapSetOperations(t *testing.T) {
+	set := NewCapSet(CAPCHOWN, CAPNetRaw)
+
+	if !set.Has(CAPCHOWN) || !set.Has(CAPNetRaw) {
+		t.Errorf("Expected chown and net_raw in %s", set)
+	}
+
+	if set.Has(CAPSysAdmin) {
+		t.Errorf("sys_admin is not expected in %s", set)
+	}
+
+	set = set.Add(CAPSysAdmin).Drop(CAPCHOWN)
+	if set.Len() != 2 {
+		t.Errorf("Expected 2 capabilities, got %d", set.Len())
+	}
+
+	if set.String() != "net_raw,sys_admin" {
+		t.Errorf("Expected 'net_raw,sys_admin', got '%s'", set)
+	}
+
+	if CapSet(0).String() != "none" {
+		t.Errorf("Expected 'none', got '%s'", CapSet(0))
+	}
+
+	if !NewCapSet(CAPNetRaw).IsSubset(set) {
+		t.Errorf("Expected net_raw to be a subset of %s", set)
+	}
+
+	if set.Difference(NewCapSet(CAPNetRaw)) != NewCapSet(CAPSysAdmin) {
+		t.Errorf("Expected difference to be sys_admin, got %s", set.Difference(NewCapSet(CAPNetRaw)))
+	}
+
+	if set.Add(Capability(64)) != set {
+		t.Error("Expected capabilities above 63 to be ignored")
+	}
+}
+
+func TestCapabilitiesGetSet(t *testing.T) {
+	var caps Capabilities
+	set := NewCapSet(CAPKill)
+
+	caps.Set(TypeEffective|TypePermitted, set)
+	toCheck := []struct {
+		t        Type
+		expected CapSet
+	}{
+		{t: TypeEffective, expected: set},
+		{t: TypePermitted, expected: set},
+		{t: TypeInheritable, expected: 0},
+		{t: TypeBoundingSet, expected: 0},
+		{t: TypeAmbient, expected: 0},
+		{t: TypeEffective | TypePermitted, expected: 0},
+	}
+
+	for _, check := range toCheck {
+		if caps.Get(check.t) != check.expected {
+			t.Errorf("'%s' expected '%s' got '%s'",
+				check.t, check.expected, caps.Get(check.t),
+			)
+		}
+	}
+}
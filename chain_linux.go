@@ -0,0 +1,246 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxInterpreterDepth is the number of "#!" interpreters the kernel follows
+const maxInterpreterDepth = 4
+
+// ExecHop is the state of the process after one execution of a chain
+type ExecHop struct {
+	// Path is the path as given to AnalyzeExecChain
+	Path string
+	// Executable is the file the kernel takes the set-id bits and the file
+	// capabilities from: Path itself, or its interpreter for "#!" scripts.
+	Executable string
+
+	File    FileCaps
+	SetUID  bool
+	SetGID  bool
+	FileUID int
+	FileGID int
+	NoSUID  bool
+
+	// State is the state of the process after the execution
+	State ProcessState
+	// Gained holds the permitted capabilities this hop added
+	Gained CapSet
+	// GainsPrivilege is true when the hop adds permitted capabilities, or
+	// changes the effective uid or gid.
+	GainsPrivilege bool
+}
+
+// AnalyzeExecChain returns the state of a process after each execution of an
+// ordered list of executables, starting from start, without running any of
+// them.
+//
+// The file capabilities and the set-id bits are read from the disk, and the
+// kernel rules of SimulateExec are applied at every hop. For "#!" scripts, the
+// kernel ignores the bits and capabilities of the script itself, and uses the
+// ones of the interpreter instead.
+//
+// When one of the executables cannot be executed with the state it is given
+// (see SimulateExec), the hops analyzed so far are returned together with
+// the error.
+func AnalyzeExecChain(start ProcessState, paths []string) ([]ExecHop, error) {
+	hops := make([]ExecHop, 0, len(paths))
+	state := start
+
+	for _, path := range paths {
+		hop, err := readExecHop(path)
+		if err != nil {
+			return hops, err
+		}
+
+		result, err := SimulateExec(state.Caps, hop.File, ExecOptions{
+			UID:        state.UID,
+			GID:        state.GID,
+			FileUID:    hop.FileUID,
+			FileGID:    hop.FileGID,
+			SetUID:     hop.SetUID,
+			SetGID:     hop.SetGID,
+			Securebits: state.Securebits,
+			NoNewPrivs: state.NoNewPrivs,
+			NoSUID:     hop.NoSUID,
+		})
+		if err != nil {
+			return hops, fmt.Errorf("%s: %w", path, err)
+		}
+
+		hop.State = ProcessState{
+			PID:        state.PID,
+			Caps:       result.Caps,
+			UID:        result.UID,
+			GID:        result.GID,
+			Securebits: result.Securebits,
+			NoNewPrivs: state.NoNewPrivs,
+		}
+		hop.Gained = result.Gained
+		hop.GainsPrivilege = !result.Gained.IsEmpty() ||
+			result.UID.Effective != state.UID.Effective ||
+			result.GID.Effective != state.GID.Effective
+
+		hops = append(hops, hop)
+		state = hop.State
+	}
+
+	return hops, nil
+}
+
+// readExecHop reads everything execve looks at for path
+func readExecHop(path string) (ExecHop, error) {
+	hop := ExecHop{Path: path, Executable: path}
+
+	for depth := 0; ; depth++ {
+		interpreter, err := readInterpreter(hop.Executable)
+		if err != nil {
+			return hop, err
+		}
+		if interpreter == "" {
+			break
+		}
+		if depth == maxInterpreterDepth {
+			return hop, fmt.Errorf("%s: %w", path, syscall.ELOOP)
+		}
+		hop.Executable = interpreter
+	}
+
+	info, err := os.Stat(hop.Executable)
+	if err != nil {
+		return hop, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		hop.FileUID, hop.FileGID = int(stat.Uid), int(stat.Gid)
+	}
+	hop.SetUID = info.Mode()&os.ModeSetuid != 0
+	// set-gid without group execute is mandatory locking, not set-gid
+	hop.SetGID = info.Mode()&os.ModeSetgid != 0 && info.Mode()&0010 != 0
+
+	hop.File, err = ReadFileCaps(hop.Executable)
+	if err != nil {
+		return hop, err
+	}
+
+	hop.NoSUID, err = mountedNoSUID(hop.Executable)
+	return hop, err
+}
+
+// readInterpreter returns the interpreter of a "#!" script, or an empty
+// string for any other file.
+func readInterpreter(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// the kernel reads up to BINPRM_BUF_SIZE bytes of the first line
+	buf := make([]byte, 256)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	buf = buf[:n]
+
+	if !bytes.HasPrefix(buf, []byte("#!")) {
+		return "", nil
+	}
+	if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
+		buf = buf[:idx]
+	}
+
+	fields := strings.Fields(string(buf[2:]))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s: %w", path, syscall.ENOEXEC)
+	}
+	return fields[0], nil
+}
+
+// mountedNoSUID returns true if path is on a filesystem mounted with nosuid
+func mountedNoSUID(path string) (bool, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	best, noSUID := "", false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		mountPoint := unescapeMountInfo(fields[4])
+		if !pathUnder(resolved, mountPoint) || len(mountPoint) < len(best) {
+			continue
+		}
+		// later mounts on the same point hide the previous ones
+		best = mountPoint
+		noSUID = false
+		for _, option := range strings.Split(fields[5], ",") {
+			if option == "nosuid" {
+				noSUID = true
+			}
+		}
+	}
+
+	return noSUID, scanner.Err()
+}
+
+// pathUnder returns true if path is dir or inside of it
+func pathUnder(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
+
+// unescapeMountInfo decodes the octal escapes (\040 for space...) used by
+// /proc/self/mountinfo
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			var value byte
+			valid := true
+			for _, c := range []byte(s[i+1 : i+4]) {
+				if c < '0' || c > '7' {
+					valid = false
+					break
+				}
+				value = value*8 + c - '0'
+			}
+			if valid {
+				b.WriteByte(value)
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeExecChain(t *testing.T) {
+	dir := t.TempDir()
+
+	binary := filepath.Join(dir, "binary")
+	if err := os.WriteFile(binary, []byte("\x7fELF"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "wrapper.sh")
+	if err := os.WriteFile(script, []byte("#!"+binary+" -e\nexec true\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	setuid := filepath.Join(dir, "setuid")
+	if err := os.WriteFile(setuid, []byte("\x7fELF"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(setuid, 0755|os.ModeSetuid); err != nil {
+		t.Fatal(err)
+	}
+
+	user := IDs{Real: os.Getuid() + 1, Effective: os.Getuid() + 1, Saved: os.Getuid() + 1, FS: os.Getuid() + 1}
+	start := ProcessState{
+		UID:  user,
+		GID:  user,
+		Caps: Capabilities{Bounding: NewCapSet(CAPNetRaw)},
+	}
+
+	hops, err := AnalyzeExecChain(start, []string{script, setuid})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(hops) != 2 {
+		t.Fatalf("Expected 2 hops, got %d", len(hops))
+	}
+
+	if hops[0].Executable != binary {
+		t.Errorf("Expected script to be executed by %s, got %s", binary, hops[0].Executable)
+	}
+
+	if hops[0].GainsPrivilege {
+		t.Errorf("Expected %s not to gain privileges", script)
+	}
+
+	if hops[1].NoSUID {
+		if hops[1].GainsPrivilege {
+			t.Errorf("Expected %s on a nosuid mount not to gain privileges", setuid)
+		}
+		return
+	}
+
+	if !hops[1].GainsPrivilege || hops[1].State.UID.Effective != os.Getuid() {
+		t.Errorf("Expected %s to change the effective uid to %d, got %+v",
+			setuid, os.Getuid(), hops[1].State.UID,
+		)
+	}
+}
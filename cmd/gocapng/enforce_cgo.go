@@ -0,0 +1,17 @@
+//go:build linux && cgo
+
+package main
+
+import (
+	"runtime"
+
+	"github.com/ik5/gocapng"
+)
+
+// enforce applies p with libcap-ng, which only changes the calling thread.
+// The goroutine stays locked to the thread, so that the command executed
+// afterwards inherits its state.
+func enforce(p *gocapng.Policy) (gocapng.DryRun, error) {
+	runtime.LockOSThread()
+	return gocapng.Init().EnforcePolicyCallingThread(p)
+}
@@ -0,0 +1,12 @@
+//go:build linux && !cgo
+
+package main
+
+import "github.com/ik5/gocapng"
+
+// enforce applies p to every thread of the process with system calls,
+// without libcap-ng: only the cgo build exercises ChangeID and the other
+// libcap-ng functions end to end
+func enforce(p *gocapng.Policy) (gocapng.DryRun, error) {
+	return gocapng.EnforcePolicyAllThreads(p)
+}
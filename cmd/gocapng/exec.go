@@ -0,0 +1,269 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ik5/gocapng"
+)
+
+// execOptions are the flags of the exec command
+type execOptions struct {
+	keep, drop, inheritable, ambient, boundingDrop string
+	user, group, securebits                        string
+	noNewPrivs, print                              bool
+	securebitsSet                                  bool
+}
+
+func runExec(args []string, stdout io.Writer) error {
+	var opts execOptions
+
+	flags := newFlagSet("exec", "[options] [--] [command [argument ...]]")
+	flags.StringVar(&opts.keep, "keep", "", "comma separated capabilities to keep in the permitted and effective sets, and in the bounding set when allowed, dropping the others")
+	flags.StringVar(&opts.drop, "drop", "", "comma separated capabilities to drop from every set, including the bounding set when allowed")
+	flags.StringVar(&opts.inheritable, "inh", "", "comma separated capabilities of the inheritable set")
+	flags.StringVar(&opts.ambient, "ambient", "", "comma separated capabilities of the ambient set, added to the inheritable set")
+	flags.StringVar(&opts.boundingDrop, "bounding-drop", "", "comma separated capabilities to drop from the bounding set")
+	flags.StringVar(&opts.user, "user", "", "user `name or id` to switch to")
+	flags.StringVar(&opts.group, "group", "", "group `name or id` to switch to, the primary group of -user by default")
+	flags.Func("securebits", "comma separated securebits to set, \"none\" to clear them", func(value string) error {
+		opts.securebits, opts.securebitsSet = value, true
+		return nil
+	})
+	flags.BoolVar(&opts.noNewPrivs, "nnp", false, "set no_new_privs")
+	flags.BoolVar(&opts.print, "print", false, "print the state before executing the command in the layout of capsh --print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 && !opts.print {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	var path string
+	if flags.NArg() > 0 {
+		var err error
+		// looked up before changing the user, which may not see the command
+		if path, err = exec.LookPath(flags.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	before, err := gocapng.ReadProcessState(0)
+	if err != nil {
+		return err
+	}
+	policy, err := execPolicy(opts, before)
+	if err != nil {
+		return err
+	}
+
+	state := before
+	if policy != nil {
+		run, err := enforce(policy)
+		if err != nil {
+			return err
+		}
+		state = run.After
+	}
+
+	if opts.print {
+		if err := printState(stdout, state); err != nil {
+			return err
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	return syscall.Exec(path, flags.Args(), os.Environ())
+}
+
+// execPolicy returns the policy applying opts to the process in state, or
+// nil when opts change nothing
+func execPolicy(opts execOptions, state gocapng.ProcessState) (*gocapng.Policy, error) {
+	if opts.keep == "" && opts.drop == "" && opts.inheritable == "" && opts.ambient == "" &&
+		opts.boundingDrop == "" && opts.user == "" && opts.group == "" &&
+		!opts.securebitsSet && !opts.noNewPrivs {
+		return nil, nil
+	}
+
+	caps := state.Caps
+	// the ambient set is cleared unless requested, as capsh --noamb
+	caps.Ambient = 0
+	// root regains the bounding set when executing the command, so the
+	// capabilities dropped from the permitted set are dropped from it too.
+	// Shrinking it needs setpcap, which other users seldom hold and have no
+	// use for, as they do not regain the bounding set.
+	shrinkBounding := state.UID.Effective == 0 || state.Caps.Effective.Has(gocapng.CAPSetPCap)
+	limitBounding := false
+
+	var keep gocapng.CapSet
+	if opts.keep != "" {
+		var err error
+		if keep, err = parseCapabilities(opts.keep); err != nil {
+			return nil, err
+		}
+		caps.Permitted, caps.Effective = keep, keep
+		limitBounding = shrinkBounding
+	}
+
+	if opts.inheritable != "" {
+		inheritable, err := parseCapabilities(opts.inheritable)
+		if err != nil {
+			return nil, err
+		}
+		caps.Inheritable = inheritable
+	}
+
+	if opts.ambient != "" {
+		ambient, err := parseCapabilities(opts.ambient)
+		if err != nil {
+			return nil, err
+		}
+		caps.Ambient = ambient
+		caps.Inheritable = caps.Inheritable.Union(ambient)
+	}
+
+	if opts.drop != "" {
+		drop, err := parseCapabilities(opts.drop)
+		if err != nil {
+			return nil, err
+		}
+		caps.Effective = caps.Effective.Difference(drop)
+		caps.Permitted = caps.Permitted.Difference(drop)
+		caps.Inheritable = caps.Inheritable.Difference(drop)
+		caps.Ambient = caps.Ambient.Difference(drop)
+		if shrinkBounding {
+			caps.Bounding = caps.Bounding.Difference(drop)
+		}
+	}
+	if limitBounding {
+		// the inheritable set can only be raised within the bounding set
+		caps.Bounding = caps.Bounding.Intersect(keep.Union(caps.Inheritable))
+	}
+
+	policy := &gocapng.Policy{
+		Version:     gocapng.PolicyVersion,
+		Name:        "exec",
+		Effective:   names(caps.Effective),
+		Permitted:   names(caps.Permitted),
+		Inheritable: names(caps.Inheritable),
+		Ambient:     names(caps.Ambient),
+		User:        opts.user,
+		Group:       opts.group,
+		NoNewPrivs:  opts.noNewPrivs,
+	}
+
+	if opts.boundingDrop != "" {
+		drop, err := parseCapabilities(opts.boundingDrop)
+		if err != nil {
+			return nil, err
+		}
+		caps.Bounding = caps.Bounding.Difference(drop)
+	}
+	if caps.Bounding != state.Caps.Bounding {
+		policy.Bounding = names(caps.Bounding)
+	}
+
+	if opts.securebitsSet {
+		policy.Securebits = strings.Split(opts.securebits, ",")
+		if _, err := gocapng.ParseSecurebits(policy.Securebits...); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// printState writes state in the layout of capsh --print
+func printState(w io.Writer, state gocapng.ProcessState) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Current: %s\n", state.Caps.Text())
+	fmt.Fprintf(&b, "Bounding set =%s\n", prefixedNames(state.Caps.Bounding))
+	fmt.Fprintf(&b, "Ambient set =%s\n", prefixedNames(state.Caps.Ambient))
+
+	noNewPrivs := 0
+	if state.NoNewPrivs {
+		noNewPrivs = 1
+	}
+	value := uint(state.Securebits)
+	width := bits.Len(value)
+	if width == 0 {
+		width = 1
+	}
+	fmt.Fprintf(&b, "Securebits: 0%o/0x%x/%d'b%b (no-new-privs=%d)\n", value, value, width, value, noNewPrivs)
+	for _, bit := range []struct {
+		name         string
+		set, locking gocapng.Securebits
+	}{
+		{"secure-noroot", gocapng.SecureNoRoot, gocapng.SecureNoRootLocked},
+		{"secure-no-suid-fixup", gocapng.SecureNoSetUIDFixup, gocapng.SecureNoSetUIDFixupLocked},
+		{"secure-keep-caps", gocapng.SecureKeepCaps, gocapng.SecureKeepCapsLocked},
+		{"secure-no-ambient-raise", gocapng.SecureNoCapAmbientRaise, gocapng.SecureNoCapAmbientRaiseLocked},
+	} {
+		set, locked := "no", "unlocked"
+		if state.Securebits&bit.set != 0 {
+			set = "yes"
+		}
+		if state.Securebits&bit.locking != 0 {
+			locked = "locked"
+		}
+		fmt.Fprintf(&b, " %s: %s (%s)\n", bit.name, set, locked)
+	}
+
+	fmt.Fprintf(&b, "uid=%d(%s) euid=%d(%s)\n",
+		state.UID.Real, userName(state.UID.Real), state.UID.Effective, userName(state.UID.Effective))
+	fmt.Fprintf(&b, "gid=%d(%s)\n", state.GID.Real, groupName(state.GID.Real))
+
+	groups, err := syscall.Getgroups()
+	if err != nil {
+		return err
+	}
+	list := make([]string, 0, len(groups))
+	for _, gid := range groups {
+		list = append(list, fmt.Sprintf("%d(%s)", gid, groupName(gid)))
+	}
+	fmt.Fprintf(&b, "groups=%s\n", strings.Join(list, ","))
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// prefixedNames returns the names of set with the "cap_" prefix of libcap,
+// separated by commas
+func prefixedNames(set gocapng.CapSet) string {
+	list := names(set)
+	for i, name := range list {
+		if !strings.HasPrefix(name, "cap_") {
+			list[i] = "cap_" + name
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+var groupNames = map[int]string{}
+
+// groupName returns the name of gid, or gid itself when it has no name
+func groupName(gid int) string {
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+	name := strconv.Itoa(gid)
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	groupNames[gid] = name
+	return name
+}
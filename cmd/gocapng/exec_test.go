@@ -0,0 +1,226 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+// execChildEnv holds the arguments of runExec, separated by newlines, in
+// the test binary re-executed by TestExecEndToEnd
+const execChildEnv = "GOCAPNG_EXEC_TEST_ARGS"
+
+func TestExecPolicy(t *testing.T) {
+	full := gocapng.FullCapSet()
+	state := gocapng.ProcessState{
+		Caps: gocapng.Capabilities{
+			Effective: full,
+			Permitted: full,
+			Bounding:  full,
+			Ambient:   gocapng.NewCapSet(gocapng.CAPKill),
+		},
+	}
+
+	policy, err := execPolicy(execOptions{print: true}, state)
+	if err != nil || policy != nil {
+		t.Errorf("Expected no policy for -print only, have %+v (%v)", policy, err)
+	}
+
+	policy, err = execPolicy(execOptions{
+		keep:         "net_raw,net_bind_service,sys_admin",
+		drop:         "sys_admin",
+		ambient:      "net_bind_service",
+		boundingDrop: "sys_module",
+		user:         "nobody",
+		securebits:   "noroot,noroot_locked",
+		noNewPrivs:   true,
+	}, state)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if policy.Securebits != nil {
+		t.Errorf("Expected securebits to be kept without -securebits, have %v", policy.Securebits)
+	}
+
+	resolved, err := policy.Resolve()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := gocapng.Capabilities{
+		Effective:   gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService),
+		Permitted:   gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService),
+		Inheritable: gocapng.NewCapSet(gocapng.CAPNetBindService),
+		Ambient:     gocapng.NewCapSet(gocapng.CAPNetBindService),
+		Bounding:    gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService),
+	}
+	if resolved.Caps != expected {
+		t.Errorf("Expected %+v but have %+v instead", expected, resolved.Caps)
+	}
+	if policy.User != "nobody" || !policy.NoNewPrivs {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	policy, err = execPolicy(execOptions{drop: "sys_admin", boundingDrop: "sys_module"}, state)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved, err = policy.Resolve(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if bounding := full.Drop(gocapng.CAPSysAdmin, gocapng.CAPSysModule); resolved.Caps.Bounding != bounding {
+		t.Errorf("Expected the bounding set %s but have %s instead", bounding, resolved.Caps.Bounding)
+	}
+
+	policy, err = execPolicy(execOptions{inheritable: "kill"}, state)
+	if err != nil || policy.Bounding != nil {
+		t.Errorf("Expected the bounding set to be kept, have %+v (%v)", policy, err)
+	}
+
+	// without root nor setpcap, the bounding set cannot shrink
+	user := state
+	user.UID = gocapng.IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+	user.Caps.Effective = full.Drop(gocapng.CAPSetPCap)
+	policy, err = execPolicy(execOptions{keep: "net_raw", drop: "sys_admin"}, user)
+	if err != nil || policy.Bounding != nil {
+		t.Errorf("Expected the bounding set to be kept without setpcap, have %+v (%v)", policy, err)
+	}
+	user.Caps.Effective = full
+	policy, err = execPolicy(execOptions{drop: "sys_admin"}, user)
+	if err != nil || !reflect.DeepEqual(policy.Bounding, names(full.Drop(gocapng.CAPSysAdmin))) {
+		t.Errorf("Expected the bounding set to shrink with setpcap, have %+v (%v)", policy, err)
+	}
+
+	for _, opts := range []execOptions{
+		{keep: "unknown"},
+		{securebits: "unknown", securebitsSet: true},
+	} {
+		if _, err := execPolicy(opts, state); err == nil {
+			t.Errorf("'%+v' expected an error", opts)
+		}
+	}
+}
+
+func TestPrintState(t *testing.T) {
+	state := gocapng.ProcessState{
+		Caps: gocapng.Capabilities{
+			Effective: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Bounding:  gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPKill),
+			Ambient:   gocapng.NewCapSet(gocapng.CAPNetRaw),
+		},
+		Securebits: gocapng.SecureNoRoot | gocapng.SecureNoRootLocked | gocapng.SecureKeepCaps,
+		NoNewPrivs: true,
+	}
+
+	var b strings.Builder
+	if err := printState(&b, state); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := "Current: cap_net_raw=ep\n" +
+		"Bounding set =cap_kill,cap_net_raw\n" +
+		"Ambient set =cap_net_raw\n" +
+		"Securebits: 023/0x13/5'b10011 (no-new-privs=1)\n" +
+		" secure-noroot: yes (locked)\n" +
+		" secure-no-suid-fixup: no (unlocked)\n" +
+		" secure-keep-caps: yes (unlocked)\n" +
+		" secure-no-ambient-raise: no (unlocked)\n" +
+		"uid=0(root) euid=0(root)\n" +
+		"gid=0(root)\n"
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Errorf("Expected\n%s\nbut have\n%s\ninstead", expected, b.String())
+	}
+}
+
+func TestExecEndToEnd(t *testing.T) {
+	if args := os.Getenv(execChildEnv); args != "" {
+		if err := runExec(strings.Split(args, "\n"), os.Stdout); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	gocapngtest.SkipUnlessHave(t, gocapng.CAPSetPCap, gocapng.CAPSetUID, gocapng.CAPSetGID)
+
+	args := []string{
+		"-keep", "net_raw,net_bind_service",
+		"-ambient", "net_bind_service",
+		"-bounding-drop", "sys_admin",
+		"-user", "65534", "-group", "65534",
+		"-nnp",
+		"--", "cat", "/proc/self/status",
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecEndToEnd$")
+	cmd.Env = append(os.Environ(), execChildEnv+"="+strings.Join(args, "\n"))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n%s", err, output)
+	}
+
+	dir := gocapngtest.NewProcTree(t)
+	dir.WriteFile(1, "status", string(output))
+	state, err := gocapng.ReadProcessStateAt(dir.Root, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n%s", err, output)
+	}
+
+	// the ambient set is raised into the permitted and effective sets of
+	// the command, the other capabilities are lost with the uid
+	bindService := gocapng.NewCapSet(gocapng.CAPNetBindService)
+	if state.Caps.Permitted != bindService || state.Caps.Effective != bindService || state.Caps.Ambient != bindService {
+		t.Errorf("Expected net_bind_service, have %+v", state.Caps)
+	}
+	if state.Caps.Bounding.Has(gocapng.CAPSysAdmin) {
+		t.Error("Expected sys_admin to be dropped from the bounding set")
+	}
+	if state.UID.Effective != 65534 || state.GID.Effective != 65534 || !state.NoNewPrivs {
+		t.Errorf("Unexpected credentials %+v", state)
+	}
+}
+
+func TestExecEndToEndRoot(t *testing.T) {
+	if args := os.Getenv(execChildEnv); args != "" {
+		if err := runExec(strings.Split(args, "\n"), os.Stdout); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	gocapngtest.SkipUnlessHave(t, gocapng.CAPSetPCap)
+	if state, err := gocapng.ReadProcessState(0); err != nil || state.UID.Effective != 0 {
+		t.Skip("Needs to run as root")
+	}
+
+	// without -user the command runs as root, which gains the bounding set
+	// when executed
+	args := []string{
+		"-keep", "net_raw,net_bind_service,sys_admin",
+		"-drop", "sys_admin",
+		"--", "cat", "/proc/self/status",
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecEndToEndRoot$")
+	cmd.Env = append(os.Environ(), execChildEnv+"="+strings.Join(args, "\n"))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n%s", err, output)
+	}
+
+	dir := gocapngtest.NewProcTree(t)
+	dir.WriteFile(1, "status", string(output))
+	state, err := gocapng.ReadProcessStateAt(dir.Root, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n%s", err, output)
+	}
+
+	kept := gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService)
+	if state.Caps.Permitted != kept || state.Caps.Effective != kept || state.Caps.Bounding != kept {
+		t.Errorf("Expected net_raw and net_bind_service, have %+v", state.Caps)
+	}
+}
@@ -0,0 +1,432 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ik5/gocapng"
+)
+
+// origins of a capability
+const (
+	originAmbient = "ambient"
+	originFile    = "file"
+	originRoot    = "root"
+	originParent  = "parent"
+	originUnknown = "unknown"
+)
+
+// explanation is the JSON layout of explain
+type explanation struct {
+	PID            int                     `json:"pid,omitempty"`
+	PPID           int                     `json:"ppid,omitempty"`
+	Command        string                  `json:"command,omitempty"`
+	Path           string                  `json:"path,omitempty"`
+	UID            int                     `json:"uid"`
+	GID            int                     `json:"gid"`
+	FileCaps       string                  `json:"file_capabilities,omitempty"`
+	Capabilities   []capabilityExplanation `json:"capabilities"`
+	RootEquivalent bool                    `json:"root_equivalent"`
+	NoNewPrivs     bool                    `json:"no_new_privs"`
+	// Securebits is empty when they cannot be read
+	Securebits      string   `json:"securebits,omitempty"`
+	BoundingDropped []string `json:"bounding_dropped"`
+	// Gainable holds the capabilities execve can still grant
+	Gainable    []string `json:"gainable"`
+	Constraints []string `json:"constraints"`
+
+	// summary holds the sentences introducing the text layout
+	summary []string
+}
+
+// capabilityExplanation explains a single capability held
+type capabilityExplanation struct {
+	Name           string   `json:"name"`
+	Sets           []string `json:"sets"`
+	Origin         string   `json:"origin"`
+	Reason         string   `json:"reason"`
+	Risk           string   `json:"risk"`
+	RootEquivalent bool     `json:"root_equivalent"`
+	Escalation     string   `json:"escalation,omitempty"`
+	// Description is only written as JSON, as it is the comment of
+	// linux/capability.h
+	Description string `json:"description"`
+}
+
+// explainInput is what explain knows about a process
+type explainInput struct {
+	state gocapng.ProcessState
+	// file holds the capabilities of the executable, and path its name
+	file gocapng.FileCaps
+	path string
+	// parent is nil when unknown
+	parent *gocapng.ProcessState
+	// securebits is false when the securebits of state cannot be read
+	securebits bool
+}
+
+func runExplain(args []string, stdout io.Writer) error {
+	flags := newFlagSet("explain", "[-json] {pid | path}")
+	asJSON := flags.Bool("json", false, "write JSON instead of text")
+	procRoot := flags.String("proc", "/proc", "`directory` of the proc file system")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single pid or path")
+	}
+
+	var e explanation
+	var err error
+	// a file named as a number is given as ./<number>
+	if pid, convErr := strconv.Atoi(flags.Arg(0)); convErr == nil {
+		e, err = explainProcess(*procRoot, pid)
+	} else {
+		e, err = explainFile(flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(stdout, e)
+	}
+	return writeExplanation(stdout, e)
+}
+
+// explainProcess explains the capabilities of the process pid of procRoot
+func explainProcess(procRoot string, pid int) (explanation, error) {
+	state, err := gocapng.ReadProcessStateAt(procRoot, pid)
+	if err != nil {
+		return explanation{}, err
+	}
+	in := explainInput{state: state}
+	if procRoot == "/proc" && pid == os.Getpid() {
+		if in.state, err = gocapng.ReadProcessState(0); err != nil {
+			return explanation{}, err
+		}
+		in.securebits = true
+	}
+
+	command := "?"
+	if comm, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm")); err == nil {
+		command = strings.TrimSpace(string(comm))
+	}
+
+	if state.PPID != 0 {
+		if parent, err := gocapng.ReadProcessStateAt(procRoot, state.PPID); err == nil {
+			in.parent = &parent
+		}
+	}
+
+	var summary []string
+	parent := ""
+	if state.PPID != 0 {
+		parent = fmt.Sprintf(", with the parent %d", state.PPID)
+	}
+	summary = append(summary, fmt.Sprintf("Process %d (%s) runs as uid %d(%s) and gid %d(%s)%s.",
+		pid, command, state.UID.Effective, userName(state.UID.Effective),
+		state.GID.Effective, groupName(state.GID.Effective), parent,
+	))
+
+	// the file capabilities are read through the exe link, which also
+	// reaches executables outside of the mount namespace of the caller
+	exe := filepath.Join(procRoot, strconv.Itoa(pid), "exe")
+	in.path, err = os.Readlink(exe)
+	if err == nil {
+		in.file, err = gocapng.ReadFileCaps(exe)
+	}
+	switch {
+	case err != nil:
+		summary = append(summary, fmt.Sprintf("The executable cannot be read: %s.", err))
+	case in.file.Version == 0:
+		summary = append(summary, fmt.Sprintf("The executable %s has no file capabilities.", in.path))
+	default:
+		summary = append(summary, fmt.Sprintf("The executable %s has the file capabilities %s.", in.path, in.file))
+	}
+
+	e := explain(in)
+	e.PID, e.PPID, e.Command = pid, state.PPID, command
+	e.summary = append(summary, e.summary...)
+	return e, nil
+}
+
+// explainFile explains the capabilities the calling process would hold after
+// executing path
+func explainFile(path string) (explanation, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return explanation{}, err
+	}
+	file, err := gocapng.ReadFileCaps(path)
+	if err != nil {
+		return explanation{}, err
+	}
+	caller, err := gocapng.ReadProcessState(0)
+	if err != nil {
+		return explanation{}, err
+	}
+
+	opts := gocapng.ExecOptions{
+		UID:        caller.UID,
+		GID:        caller.GID,
+		SetUID:     info.Mode()&os.ModeSetuid != 0,
+		SetGID:     info.Mode()&os.ModeSetgid != 0,
+		Securebits: caller.Securebits,
+		NoNewPrivs: caller.NoNewPrivs,
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		opts.FileUID, opts.FileGID = int(stat.Uid), int(stat.Gid)
+	}
+	var fs syscall.Statfs_t
+	// ST_NOSUID of statfs has the value of MS_NOSUID
+	if syscall.Statfs(path, &fs) == nil && fs.Flags&syscall.MS_NOSUID != 0 {
+		opts.NoSUID = true
+	}
+
+	var summary []string
+	mode := ""
+	if opts.SetUID {
+		mode += ", setuid"
+	}
+	if opts.SetGID {
+		mode += ", setgid"
+	}
+	summary = append(summary, fmt.Sprintf("File %s is owned by uid %d(%s) and gid %d(%s)%s.",
+		path, opts.FileUID, userName(opts.FileUID), opts.FileGID, groupName(opts.FileGID), mode,
+	))
+	if file.Version == 0 {
+		summary = append(summary, "It has no file capabilities.")
+	} else {
+		summary = append(summary, fmt.Sprintf("It has the file capabilities %s.", file))
+	}
+	if opts.NoSUID {
+		summary = append(summary, "Its file system is mounted nosuid, ignoring the set-id bits and the file capabilities.")
+	}
+
+	result, err := gocapng.SimulateExec(caller.Caps, file, opts)
+	if errors.Is(err, gocapng.ErrExecFileCapabilitiesNotGranted) {
+		return explanation{}, fmt.Errorf("%s: the kernel refuses to execute the file for uid %d: %w",
+			path, caller.UID.Effective, err)
+	}
+	if err != nil {
+		return explanation{}, err
+	}
+	summary = append(summary, fmt.Sprintf("Executed by uid %d(%s), it runs as uid %d(%s) and gid %d(%s).",
+		caller.UID.Effective, userName(caller.UID.Effective),
+		result.UID.Effective, userName(result.UID.Effective),
+		result.GID.Effective, groupName(result.GID.Effective),
+	))
+
+	e := explain(explainInput{
+		state: gocapng.ProcessState{
+			PID:        caller.PID,
+			Caps:       result.Caps,
+			UID:        result.UID,
+			GID:        result.GID,
+			Securebits: result.Securebits,
+			NoNewPrivs: caller.NoNewPrivs,
+		},
+		file:       file,
+		path:       path,
+		parent:     &caller,
+		securebits: true,
+	})
+	e.summary = append(summary, e.summary...)
+	return e, nil
+}
+
+// explain returns why the process of in holds each of its capabilities, and
+// what keeps it from gaining others
+func explain(in explainInput) explanation {
+	state, caps := in.state, in.state.Caps
+
+	e := explanation{
+		Path:            in.path,
+		UID:             state.UID.Effective,
+		GID:             state.GID.Effective,
+		Capabilities:    []capabilityExplanation{},
+		RootEquivalent:  gocapng.IsRootEquivalent(caps.Permitted.Union(caps.Ambient)),
+		NoNewPrivs:      state.NoNewPrivs,
+		BoundingDropped: names(gocapng.FullCapSet().Difference(caps.Bounding)),
+		Gainable:        []string{},
+	}
+	if in.file.Version != 0 {
+		e.FileCaps = in.file.String()
+	}
+
+	held := caps.Effective.Union(caps.Permitted).Union(caps.Inheritable).Union(caps.Ambient)
+	for _, capability := range held.List() {
+		info := capability.Info()
+		origin, reason := capabilityOrigin(in, capability)
+		e.Capabilities = append(e.Capabilities, capabilityExplanation{
+			Name:           info.Name,
+			Sets:           capabilitySets(caps, capability),
+			Origin:         origin,
+			Reason:         reason,
+			Risk:           info.Risk.String(),
+			RootEquivalent: info.RootEquivalent,
+			Escalation:     info.Escalation,
+			Description:    info.Description,
+		})
+	}
+
+	switch {
+	case held.IsEmpty():
+		e.summary = append(e.summary, "It holds no capabilities.")
+	case e.RootEquivalent:
+		e.summary = append(e.summary, "It holds capabilities known to lead to full root.")
+	}
+
+	if in.securebits {
+		e.Securebits = state.Securebits.String()
+	}
+	e.Constraints = constraints(in, &e)
+	return e
+}
+
+// capabilitySets returns the sets of caps holding capability
+func capabilitySets(caps gocapng.Capabilities, capability gocapng.Capability) []string {
+	sets := []string{}
+	for _, set := range []struct {
+		name string
+		set  gocapng.CapSet
+	}{
+		{"effective", caps.Effective},
+		{"permitted", caps.Permitted},
+		{"inheritable", caps.Inheritable},
+		{"ambient", caps.Ambient},
+	} {
+		if set.set.Has(capability) {
+			sets = append(sets, set.name)
+		}
+	}
+	return sets
+}
+
+// capabilityOrigin returns where the process of in most likely got
+// capability from, following the rules of execve, and the reason in prose
+func capabilityOrigin(in explainInput, capability gocapng.Capability) (string, string) {
+	state, caps, file := in.state, in.state.Caps, in.file
+	parentHas := func(set func(gocapng.Capabilities) gocapng.CapSet) bool {
+		return in.parent != nil && set(in.parent.Caps).Has(capability)
+	}
+
+	if !caps.Permitted.Has(capability) && !caps.Ambient.Has(capability) {
+		if parentHas(func(c gocapng.Capabilities) gocapng.CapSet { return c.Inheritable }) {
+			return originParent, fmt.Sprintf(
+				"Inherited from the inheritable set of the parent %d; it is only usable by programs whose file inheritable set holds it.",
+				in.parent.PID)
+		}
+		return originUnknown, "Only in the inheritable set, usable by programs whose file inheritable set holds it."
+	}
+
+	switch {
+	case caps.Ambient.Has(capability):
+		return originAmbient, "Inherited through the ambient set, which execve keeps for programs without file capabilities or set-id bits."
+	case file.Version != 0 &&
+		(file.Permitted.Has(capability) && caps.Bounding.Has(capability) ||
+			file.Inheritable.Has(capability) && caps.Inheritable.Has(capability)):
+		return originFile, fmt.Sprintf("Granted on execve by the file capabilities of %s.", in.path)
+	case (state.UID.Real == 0 || state.UID.Effective == 0) &&
+		(!in.securebits || state.Securebits&gocapng.SecureNoRoot == 0):
+		return originRoot, "Granted on execve because the process runs as root."
+	case parentHas(func(c gocapng.Capabilities) gocapng.CapSet { return c.Permitted }):
+		return originParent, fmt.Sprintf(
+			"Held by the parent %d, kept across fork without execve, or across a change of user with keep_caps.",
+			in.parent.PID)
+	default:
+		return originUnknown, "Not granted by execve: kept across a change of user, or by a process replaced since."
+	}
+}
+
+// constraints describes in prose what keeps the process of in from gaining
+// capabilities, and fills the gainable capabilities of e
+func constraints(in explainInput, e *explanation) []string {
+	state, caps := in.state, in.state.Caps
+	var lines []string
+
+	if state.NoNewPrivs {
+		lines = append(lines, "no_new_privs is set: execve of set-id programs and programs with file capabilities grants nothing new.")
+	} else {
+		gainable := caps.Bounding.Difference(caps.Permitted)
+		e.Gainable = names(gainable)
+		if gainable.IsEmpty() {
+			lines = append(lines, "no_new_privs is not set, but the bounding set holds nothing more to gain.")
+		} else {
+			lines = append(lines, fmt.Sprintf(
+				"no_new_privs is not set: execve of a setuid root program or a program with file capabilities can gain %s.",
+				setText(gainable)))
+		}
+	}
+
+	if caps.Bounding == gocapng.FullCapSet() {
+		lines = append(lines, "The bounding set holds every capability.")
+	} else {
+		lines = append(lines, fmt.Sprintf("The bounding set drops %s, which can never be gained again.",
+			setText(gocapng.FullCapSet().Difference(caps.Bounding))))
+	}
+
+	if !in.securebits {
+		lines = append(lines, "The securebits can only be read by the process itself.")
+		return lines
+	}
+	lines = append(lines, fmt.Sprintf("Securebits: %s.", state.Securebits))
+	if state.Securebits&gocapng.SecureNoRoot != 0 {
+		lines = append(lines, "noroot is set: running as root grants no capabilities on execve.")
+	}
+	if state.Securebits&gocapng.SecureNoCapAmbientRaise != 0 {
+		lines = append(lines, "no_cap_ambient_raise is set: the ambient set can no longer be raised.")
+	}
+	return lines
+}
+
+// writeExplanation writes e in prose
+func writeExplanation(w io.Writer, e explanation) error {
+	var b strings.Builder
+
+	for _, line := range e.summary {
+		fmt.Fprintln(&b, line)
+	}
+	for _, c := range e.Capabilities {
+		risk := c.Risk + " risk"
+		if c.RootEquivalent {
+			risk += ", root equivalent"
+		}
+		fmt.Fprintf(&b, "\n%s (%s)\n", c.Name, risk)
+		sets := "sets"
+		if len(c.Sets) == 1 {
+			sets = "set"
+		}
+		fmt.Fprintf(&b, "  In the %s %s.\n", joinWords(c.Sets), sets)
+		fmt.Fprintf(&b, "  %s\n", c.Reason)
+		if c.Escalation != "" {
+			fmt.Fprintf(&b, "  Leads to root: %s.\n", c.Escalation)
+		}
+	}
+
+	fmt.Fprintln(&b, "\nConstraints:")
+	for _, line := range e.Constraints {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// joinWords joins words as "a", "a and b" or "a, b and c"
+func joinWords(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
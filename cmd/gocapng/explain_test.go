@@ -0,0 +1,198 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+func TestExplainOrigins(t *testing.T) {
+	full := gocapng.FullCapSet()
+	root := gocapng.IDs{}
+	user := gocapng.IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+	parent := gocapng.ProcessState{
+		PID: 1, UID: root,
+		Caps: gocapng.Capabilities{
+			Effective:   full,
+			Permitted:   full,
+			Inheritable: gocapng.NewCapSet(gocapng.CAPSysNice),
+			Bounding:    full,
+		},
+	}
+
+	in := explainInput{
+		state: gocapng.ProcessState{
+			PID: 10, PPID: 1, UID: user, GID: user,
+			Caps: gocapng.Capabilities{
+				Effective: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService, gocapng.CAPKill),
+				Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService, gocapng.CAPKill,
+					gocapng.CAPSysTime),
+				Inheritable: gocapng.NewCapSet(gocapng.CAPNetBindService, gocapng.CAPSysNice, gocapng.CAPLease),
+				Ambient:     gocapng.NewCapSet(gocapng.CAPNetBindService),
+				Bounding:    full.Drop(gocapng.CAPSysModule),
+			},
+			NoNewPrivs: true,
+		},
+		file: gocapng.FileCaps{
+			Version:   2,
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Effective: true,
+			RootID:    gocapng.UnsetRootID,
+		},
+		path:   "/usr/bin/tool",
+		parent: &parent,
+	}
+	in.parent.Caps.Permitted = in.parent.Caps.Permitted.Drop(gocapng.CAPSysTime)
+
+	e := explain(in)
+
+	expected := map[string]string{
+		"kill":             originParent,
+		"lease":            originUnknown,
+		"net_bind_service": originAmbient,
+		"net_raw":          originFile,
+		"sys_nice":         originParent,
+		"sys_time":         originUnknown,
+	}
+	if len(e.Capabilities) != len(expected) {
+		t.Fatalf("Expected %d capabilities, have %+v", len(expected), e.Capabilities)
+	}
+	for _, c := range e.Capabilities {
+		if c.Origin != expected[c.Name] {
+			t.Errorf("'%s' expected origin '%s' but have '%s' instead", c.Name, expected[c.Name], c.Origin)
+		}
+	}
+
+	if e.Capabilities[0].Name != "kill" || e.Capabilities[0].Risk != "medium" ||
+		strings.Join(e.Capabilities[0].Sets, ",") != "effective,permitted" {
+		t.Errorf("Unexpected explanation %+v", e.Capabilities[0])
+	}
+	if e.RootEquivalent {
+		t.Error("Expected the process not to be root equivalent")
+	}
+	if len(e.Gainable) != 0 || strings.Join(e.BoundingDropped, ",") != "sys_module" {
+		t.Errorf("Unexpected constraints %v %v", e.Gainable, e.BoundingDropped)
+	}
+	if !strings.HasPrefix(e.Constraints[0], "no_new_privs is set") ||
+		e.Constraints[len(e.Constraints)-1] != "The securebits can only be read by the process itself." {
+		t.Errorf("Unexpected constraints %q", e.Constraints)
+	}
+
+	in.state.UID = root
+	in.state.NoNewPrivs = false
+	in.securebits = true
+	e = explain(in)
+	for _, c := range e.Capabilities {
+		if c.Name == "sys_time" && c.Origin != originRoot {
+			t.Errorf("'%s' expected origin '%s' but have '%s' instead", c.Name, originRoot, c.Origin)
+		}
+	}
+	if len(e.Gainable) == 0 || e.Securebits != "none" {
+		t.Errorf("Unexpected constraints %v %q", e.Gainable, e.Securebits)
+	}
+
+	in.state.Securebits = gocapng.SecureNoRoot
+	e = explain(in)
+	for _, c := range e.Capabilities {
+		if c.Name == "sys_time" && c.Origin != originUnknown {
+			t.Errorf("'%s' expected origin '%s' with noroot but have '%s' instead", c.Name, originUnknown, c.Origin)
+		}
+	}
+}
+
+func TestExplainProcess(t *testing.T) {
+	full := gocapng.FullCapSet()
+	root := gocapng.IDs{}
+	user := gocapng.IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+
+	tree := gocapngtest.NewProcTree(t)
+	tree.Add("init", gocapng.ProcessState{
+		PID: 1, UID: root, GID: root,
+		Caps: gocapng.Capabilities{Effective: full, Permitted: full, Bounding: full},
+	})
+	tree.Add("daemon", gocapng.ProcessState{
+		PID: 10, PPID: 1, UID: user, GID: user,
+		Caps: gocapng.Capabilities{
+			Effective:   gocapng.NewCapSet(gocapng.CAPSysAdmin),
+			Permitted:   gocapng.NewCapSet(gocapng.CAPSysAdmin, gocapng.CAPNetBindService),
+			Inheritable: gocapng.NewCapSet(gocapng.CAPNetBindService),
+			Ambient:     gocapng.NewCapSet(gocapng.CAPNetBindService),
+			Bounding:    full,
+		},
+	})
+
+	var b bytes.Buffer
+	if err := runExplain([]string{"-proc", tree.Root, "-json", "10"}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var e explanation
+	if err := json.Unmarshal(b.Bytes(), &e); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if e.PID != 10 || e.PPID != 1 || e.Command != "daemon" || e.UID != 1000 || !e.RootEquivalent {
+		t.Errorf("Unexpected explanation %+v", e)
+	}
+	if len(e.Capabilities) != 2 ||
+		e.Capabilities[0].Name != "net_bind_service" || e.Capabilities[0].Origin != originAmbient ||
+		e.Capabilities[1].Name != "sys_admin" || e.Capabilities[1].Origin != originParent ||
+		e.Capabilities[1].Risk != "critical" || e.Capabilities[1].Escalation == "" {
+		t.Errorf("Unexpected capabilities %+v", e.Capabilities)
+	}
+
+	b.Reset()
+	if err := runExplain([]string{"-proc", tree.Root, "10"}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, line := range []string{
+		"Process 10 (daemon) runs as uid 1000(",
+		"\nThe executable cannot be read: ",
+		"\nIt holds capabilities known to lead to full root.\n",
+		"\nsys_admin (critical risk, root equivalent)\n  In the effective and permitted sets.\n  Held by the parent 1,",
+		"\nnet_bind_service (low risk)\n  In the permitted, inheritable and ambient sets.\n",
+		"\nConstraints:\n  no_new_privs is not set: ",
+		"\n  The bounding set holds every capability.\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Expected '%s' in\n%s", line, b.String())
+		}
+	}
+
+	if err := runExplain([]string{"-proc", tree.Root, "20"}, &b); err == nil {
+		t.Error("Expected an error for a missing process")
+	}
+	if err := runExplain([]string{"-proc", tree.Root}, &b); err == nil {
+		t.Error("Expected an error without a target")
+	}
+}
+
+func TestExplainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain")
+	writeFile(t, path, 0o755)
+
+	caller, err := gocapng.ReadProcessState(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	e, err := explainFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if e.Path != path || e.FileCaps != "" || e.UID != caller.UID.Effective {
+		t.Errorf("Unexpected explanation %+v", e)
+	}
+	if e.summary[1] != "It has no file capabilities." {
+		t.Errorf("Unexpected summary %q", e.summary)
+	}
+	if !strings.HasPrefix(e.summary[2], "Executed by uid "+strconv.Itoa(caller.UID.Effective)+"(") {
+		t.Errorf("Unexpected summary %q", e.summary)
+	}
+}
@@ -0,0 +1,267 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/ik5/gocapng"
+)
+
+// fileEntry is a file holding capabilities, or a setuid/setgid file
+type fileEntry struct {
+	Path        string   `json:"path"`
+	UID         int      `json:"uid"`
+	GID         int      `json:"gid"`
+	Version     int      `json:"version,omitempty"`
+	Permitted   []string `json:"permitted"`
+	Inheritable []string `json:"inheritable"`
+	Effective   bool     `json:"effective"`
+	RootID      *int     `json:"rootid,omitempty"`
+	SetUID      bool     `json:"setuid,omitempty"`
+	SetGID      bool     `json:"setgid,omitempty"`
+}
+
+// fileScan walks directories for files holding capabilities
+type fileScan struct {
+	setID  bool
+	follow bool
+	xdev   bool
+	warn   io.Writer
+
+	// visited holds the directories already walked when following symbolic
+	// links, by device and inode
+	visited map[[2]uint64]bool
+	found   []fileEntry
+}
+
+func runFilecap(args []string, stdout io.Writer) error {
+	flags := newFlagSet("filecap", "[-setuid] [-L] [-xdev] [-json] [path ...]")
+	setID := flags.Bool("setuid", false, "include setuid and setgid files")
+	follow := flags.Bool("L", false, "follow symbolic links")
+	xdev := flags.Bool("xdev", false, "stay on the file system of every path")
+	asJSON := flags.Bool("json", false, "write JSON instead of text")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	defaults := len(paths) == 0
+	if defaults {
+		paths = pathDirs(os.Getenv("PATH"))
+	}
+
+	scan := &fileScan{
+		setID:   *setID,
+		follow:  *follow,
+		xdev:    *xdev,
+		warn:    os.Stderr,
+		visited: make(map[[2]uint64]bool),
+	}
+	for _, path := range paths {
+		err := scan.walk(path, 0)
+		if defaults && errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			scan.warnf(path, err)
+		}
+	}
+
+	if *asJSON {
+		if scan.found == nil {
+			scan.found = []fileEntry{}
+		}
+		return writeJSON(stdout, scan.found)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tPERMITTED\tINHERITABLE\tEFFECTIVE\tROOTID\tMODE")
+	for _, entry := range scan.found {
+		rootID := "-"
+		if entry.RootID != nil {
+			rootID = strconv.Itoa(*entry.RootID)
+		}
+		var mode []string
+		if entry.SetUID {
+			mode = append(mode, "setuid")
+		}
+		if entry.SetGID {
+			mode = append(mode, "setgid")
+		}
+		if len(mode) == 0 {
+			mode = append(mode, "-")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n",
+			entry.Path, listText(entry.Permitted), listText(entry.Inheritable),
+			entry.Effective, rootID, strings.Join(mode, ","),
+		)
+	}
+	return tw.Flush()
+}
+
+// pathDirs returns the directories of a PATH value, without duplicates
+func pathDirs(path string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// walk checks every file under root. dev is the device to stay on with xdev,
+// or 0 to use the device of root.
+func (s *fileScan) walk(root string, dev uint64) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		s.check(root, info)
+		return nil
+	}
+
+	if dev == 0 {
+		dev = deviceOf(info)
+	}
+
+	// WalkDir does not enter a root that is a symbolic link, so the target is
+	// walked and reported under the name of the link
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		path = root + strings.TrimPrefix(path, real)
+		if err != nil {
+			s.warnf(path, err)
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			if !s.follow {
+				return nil
+			}
+			target, err := os.Stat(path)
+			if err != nil {
+				s.warnf(path, err)
+				return nil
+			}
+			if target.IsDir() {
+				if !s.xdev || deviceOf(target) == dev {
+					if err := s.walk(path, dev); err != nil {
+						s.warnf(path, err)
+					}
+				}
+				return nil
+			}
+			s.check(path, target)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			s.warnf(path, err)
+			return nil
+		}
+
+		if d.IsDir() {
+			if s.xdev && deviceOf(info) != dev {
+				return filepath.SkipDir
+			}
+			if s.follow {
+				key := fileKey(info)
+				if s.visited[key] {
+					return filepath.SkipDir
+				}
+				s.visited[key] = true
+			}
+			return nil
+		}
+
+		s.check(path, info)
+		return nil
+	})
+}
+
+// check records the file at path when it holds capabilities, or is setuid or
+// setgid and those are requested
+func (s *fileScan) check(path string, info fs.FileInfo) {
+	if !info.Mode().IsRegular() {
+		return
+	}
+
+	caps, err := gocapng.ReadFileCaps(path)
+	if err != nil {
+		s.warnf(path, err)
+		return
+	}
+
+	setUID := info.Mode()&fs.ModeSetuid != 0
+	setGID := info.Mode()&fs.ModeSetgid != 0
+	if caps.Version == 0 && !(s.setID && (setUID || setGID)) {
+		return
+	}
+
+	entry := fileEntry{
+		Path:        path,
+		Version:     caps.Version,
+		Permitted:   names(caps.Permitted),
+		Inheritable: names(caps.Inheritable),
+		Effective:   caps.Effective,
+		SetUID:      setUID,
+		SetGID:      setGID,
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		entry.UID, entry.GID = int(stat.Uid), int(stat.Gid)
+	}
+	if caps.RootID != gocapng.UnsetRootID && caps.Version != 0 {
+		rootID := caps.RootID
+		entry.RootID = &rootID
+	}
+	s.found = append(s.found, entry)
+}
+
+func (s *fileScan) warnf(path string, err error) {
+	fmt.Fprintf(s.warn, "%s: %s\n", path, err)
+}
+
+func deviceOf(info fs.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+	return 0
+}
+
+func fileKey(info fs.FileInfo) [2]uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return [2]uint64{uint64(stat.Dev), stat.Ino}
+	}
+	return [2]uint64{}
+}
+
+// listText returns the names of a list in the layout of setText
+func listText(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+func runFilecapJSON(t *testing.T, args ...string) []fileEntry {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := runFilecap(append([]string{"-json"}, args...), &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal(b.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return entries
+}
+
+func entryPaths(entries []fileEntry) []string {
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestFilecapSetID(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "plain"), 0o755)
+	writeFile(t, filepath.Join(dir, "sub", "setuid"), os.ModeSetuid|0o755)
+	writeFile(t, filepath.Join(other, "setgid"), os.ModeSetgid|0o755)
+	if err := os.Symlink(other, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	type toCheck struct {
+		name     string
+		args     []string
+		expected []string
+	}
+
+	checks := []toCheck{
+		{name: "no capabilities", args: []string{dir}, expected: nil},
+		{
+			name:     "setuid",
+			args:     []string{"-setuid", dir},
+			expected: []string{filepath.Join(dir, "sub", "setuid")},
+		},
+		{
+			name:     "follow",
+			args:     []string{"-setuid", "-L", dir},
+			expected: []string{filepath.Join(dir, "link", "setgid"), filepath.Join(dir, "sub", "setuid")},
+		},
+	}
+
+	for _, check := range checks {
+		paths := entryPaths(runFilecapJSON(t, check.args...))
+		if len(paths) != len(check.expected) {
+			t.Errorf("'%s' expected %v but have %v instead", check.name, check.expected, paths)
+			continue
+		}
+		for i := range paths {
+			if paths[i] != check.expected[i] {
+				t.Errorf("'%s' expected %v but have %v instead", check.name, check.expected, paths)
+				break
+			}
+		}
+	}
+}
+
+func TestFilecapCapabilities(t *testing.T) {
+	gocapngtest.SkipUnlessHave(t, gocapng.CAPSetFCap)
+
+	path := filepath.Join(t.TempDir(), "ping")
+	writeFile(t, path, 0o755)
+
+	caps := gocapng.FileCaps{
+		Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+		Effective: true,
+		RootID:    1000,
+	}
+	err := syscall.Setxattr(path, "security.capability", gocapngtest.FileCapsXattr(caps), 0)
+	if err == syscall.ENOTSUP {
+		t.Skip("The file system does not support file capabilities")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := runFilecapJSON(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("Expected a single file, have %+v", entries)
+	}
+	entry := entries[0]
+	if len(entry.Permitted) != 1 || entry.Permitted[0] != "net_raw" || !entry.Effective {
+		t.Errorf("Expected net_raw+ep, have %+v", entry)
+	}
+	// the kernel stores a root id only when writing from a user namespace
+	if entry.Version == 3 && (entry.RootID == nil || *entry.RootID != 1000) {
+		t.Errorf("Expected root id 1000, have %+v", entry)
+	}
+}
+
+func writeFile(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// chmod, as the umask drops setuid and setgid on creation
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,170 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ik5/gocapng"
+)
+
+// errVerifyFailed is returned by setcap -v when a file differs
+var errVerifyFailed = errors.New("verification failed")
+
+func runGetcap(args []string, stdout io.Writer) error {
+	flags := newFlagSet("getcap", "[-r] [-n] [-v] path ...")
+	recursive := flags.Bool("r", false, "walk directories recursively")
+	showRootID := flags.Bool("n", false, "print the namespace root id of version 3 capabilities")
+	verbose := flags.Bool("v", false, "list files without capabilities too")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing path")
+	}
+
+	failed := 0
+	show := func(path string) {
+		caps, err := gocapng.ReadFileCaps(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			failed++
+			return
+		}
+		if caps.Version == 0 {
+			if *verbose {
+				fmt.Fprintln(stdout, path)
+			}
+			return
+		}
+
+		line := path + " " + caps.String()
+		if *showRootID && caps.RootID != gocapng.UnsetRootID {
+			line += fmt.Sprintf(" [rootid=%d]", caps.RootID)
+		}
+		fmt.Fprintln(stdout, line)
+	}
+
+	for _, root := range flags.Args() {
+		info, err := os.Lstat(root)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			continue
+		}
+		if !info.IsDir() || !*recursive {
+			show(root)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+				failed++
+				return nil
+			}
+			if d.Type().IsRegular() {
+				show(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to read %d files", failed)
+	}
+	return nil
+}
+
+func runSetcap(args []string, stdout io.Writer) error {
+	flags := newFlagSet("setcap", "[-q] [-n rootid] [-v] {-r path ... | caps path ...}")
+	remove := flags.Bool("r", false, "remove the capabilities of the files")
+	rootID := flags.Int("n", gocapng.UnsetRootID, "write version 3 capabilities for the namespace root `id`")
+	verify := flags.Bool("v", false, "only check that the files hold the capabilities, and fail when they do not")
+	quiet := flags.Bool("q", false, "do not report successful verifications")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	type target struct {
+		path string
+		caps gocapng.FileCaps
+	}
+
+	var targets []target
+	if *remove {
+		for _, path := range flags.Args() {
+			targets = append(targets, target{path: path, caps: gocapng.FileCaps{RootID: gocapng.UnsetRootID}})
+		}
+	} else {
+		if flags.NArg()%2 != 0 {
+			flags.Usage()
+			return errors.New("expected capabilities and path pairs")
+		}
+		for i := 0; i < flags.NArg(); i += 2 {
+			caps, err := gocapng.ParseFileCapsText(flags.Arg(i))
+			if err != nil {
+				return err
+			}
+			caps.RootID = *rootID
+			targets = append(targets, target{path: flags.Arg(i + 1), caps: caps})
+		}
+	}
+	if len(targets) == 0 {
+		flags.Usage()
+		return errors.New("missing path")
+	}
+
+	var mismatches []string
+	for _, target := range targets {
+		if *verify {
+			current, err := gocapng.ReadFileCaps(target.path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", target.path, err)
+			}
+			if !sameFileCaps(current, target.caps, *rootID != gocapng.UnsetRootID) {
+				fmt.Fprintf(stdout, "%s differs in [%s]\n", target.path, current)
+				mismatches = append(mismatches, target.path)
+			} else if !*quiet {
+				fmt.Fprintf(stdout, "%s: OK\n", target.path)
+			}
+			continue
+		}
+
+		var err error
+		if *remove {
+			err = gocapng.RemoveFileCaps(target.path)
+		} else {
+			err = gocapng.WriteFileCaps(target.path, target.caps)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", target.path, err)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%w: %s", errVerifyFailed, strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+// sameFileCaps compares the capabilities of a file with the expected ones,
+// ignoring the revision, and the root id unless requested
+func sameFileCaps(current, expected gocapng.FileCaps, rootID bool) bool {
+	if current.Permitted != expected.Permitted ||
+		current.Inheritable != expected.Inheritable ||
+		current.Effective != expected.Effective {
+		return false
+	}
+	return !rootID || current.RootID == expected.RootID
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+func TestSetcapGetcap(t *testing.T) {
+	gocapngtest.SkipUnlessHave(t, gocapng.CAPSetFCap)
+
+	dir := t.TempDir()
+	ping := filepath.Join(dir, "ping")
+	tool := filepath.Join(dir, "sub", "tool")
+	writeFile(t, ping, 0o755)
+	writeFile(t, tool, 0o755)
+	writeFile(t, filepath.Join(dir, "plain"), 0o755)
+
+	err := runSetcap([]string{"cap_net_raw+ep", ping, "cap_kill,cap_chown=p", tool}, &bytes.Buffer{})
+	if errors.Is(err, syscall.ENOTSUP) {
+		t.Skip("The file system does not support file capabilities")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var b bytes.Buffer
+	if err := runGetcap([]string{"-r", dir}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := ping + " cap_net_raw=ep\n" + tool + " cap_chown,cap_kill=p\n"
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\nbut have\n%s\ninstead", expected, b.String())
+	}
+
+	b.Reset()
+	if err := runSetcap([]string{"-v", "cap_net_raw=ep", ping}, &b); err != nil || b.String() != ping+": OK\n" {
+		t.Errorf("Expected the verification to succeed, have '%s' (%v)", b.String(), err)
+	}
+	b.Reset()
+	if err := runSetcap([]string{"-v", "-q", "cap_net_raw=p", ping}, &b); !errors.Is(err, errVerifyFailed) {
+		t.Errorf("Expected %s, have %v", errVerifyFailed, err)
+	}
+	if b.String() != ping+" differs in [cap_net_raw=ep]\n" {
+		t.Errorf("Unexpected verification output '%s'", b.String())
+	}
+
+	if err := runSetcap([]string{"-r", ping}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	b.Reset()
+	if err := runGetcap([]string{"-v", ping}, &b); err != nil || b.String() != ping+"\n" {
+		t.Errorf("Expected no capabilities, have '%s' (%v)", b.String(), err)
+	}
+}
+
+func TestSetcapArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"cap_net_raw+ep"},
+		{"cap_net_raw", "/nonexistent"},
+	} {
+		if err := runSetcap(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("'%v' expected an error", args)
+		}
+	}
+}
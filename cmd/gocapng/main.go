@@ -0,0 +1,120 @@
+//go:build linux
+
+// Command gocapng inspects the capabilities of processes and files.
+//
+// It can be built as a static binary with CGO_ENABLED=0, where exec changes
+// every thread with gocapng.EnforcePolicyAllThreads instead of libcap-ng, and
+// so does not exercise gocapng.ChangeID:
+//
+//	gocapng ps [-a] [-cap names] [-tree] [-json]
+//	gocapng filecap [-setuid] [-L] [-xdev] [-json] [path ...]
+//	gocapng netcap [-json]
+//	gocapng getcap [-r] [-n] [-v] path ...
+//	gocapng setcap [-q] [-n rootid] [-v] {-r path ... | caps path ...}
+//	gocapng exec [options] [--] [command [argument ...]]
+//	gocapng explain [-json] {pid | path}
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ik5/gocapng"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "ps", summary: "list the processes holding capabilities", run: runPS},
+	{name: "filecap", summary: "scan directories for files holding capabilities", run: runFilecap},
+	{name: "netcap", summary: "list the sockets of processes holding capabilities", run: runNetcap},
+	{name: "getcap", summary: "print the capabilities of files", run: runGetcap},
+	{name: "setcap", summary: "set, verify or remove the capabilities of files", run: runSetcap},
+	{name: "exec", summary: "run a command with a capabilities configuration", run: runExec},
+	{name: "explain", summary: "explain the capabilities of a process or a file", run: runExplain},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s <command> [arguments]\n\ncommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := cmd.run(os.Args[2:], os.Stdout)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", os.Args[0], name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", os.Args[0], name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// newFlagSet returns the flags of the command name, reporting errors to the
+// caller instead of exiting
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s %s %s\n", os.Args[0], name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseCapabilities parses a comma separated list of capability names
+func parseCapabilities(list string) (gocapng.CapSet, error) {
+	var set gocapng.CapSet
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		capability, err := gocapng.ParseCapability(name)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", err, name)
+		}
+		set |= 1 << capability
+	}
+	return set, nil
+}
+
+// setText returns the names of set, "none" for an empty set, and "full" for
+// every capability of the kernel
+func setText(set gocapng.CapSet) string {
+	if set == gocapng.FullCapSet() {
+		return "full"
+	}
+	return set.String()
+}
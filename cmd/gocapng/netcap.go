@@ -0,0 +1,263 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ik5/gocapng"
+)
+
+// tcpListen is the TCP_LISTEN state of /proc/net/tcp
+const tcpListen = "0A"
+
+// hostOrder is the byte order in which /proc/net prints the 32 bits words of
+// addresses, a variable for the tests
+var hostOrder binary.ByteOrder = binary.NativeEndian
+
+// socket is a socket read from /proc/net
+type socket struct {
+	Proto   string
+	Address string
+	// Port is the local port, the IP protocol of raw sockets, or the
+	// ethertype of packet sockets
+	Port  int
+	Inode uint64
+}
+
+// netEntry is the JSON layout of a socket owned by a process holding
+// capabilities
+type netEntry struct {
+	PID       int      `json:"pid"`
+	PPID      int      `json:"ppid"`
+	UID       int      `json:"uid"`
+	Command   string   `json:"command"`
+	Protocol  string   `json:"protocol"`
+	Address   string   `json:"address"`
+	Port      int      `json:"port"`
+	Effective []string `json:"effective"`
+	Permitted []string `json:"permitted"`
+	Ambient   []string `json:"ambient"`
+}
+
+func runNetcap(args []string, stdout io.Writer) error {
+	flags := newFlagSet("netcap", "[-json]")
+	asJSON := flags.Bool("json", false, "write JSON instead of text")
+	procRoot := flags.String("proc", "/proc", "`directory` of the proc file system")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	sockets, err := readSockets(*procRoot)
+	if err != nil {
+		return err
+	}
+	byInode := make(map[uint64][]socket, len(sockets))
+	for _, s := range sockets {
+		byInode[s.Inode] = append(byInode[s.Inode], s)
+	}
+
+	processes, err := readProcesses(*procRoot)
+	if err != nil {
+		return err
+	}
+
+	entries := []netEntry{}
+	var effective []gocapng.CapSet
+	for _, p := range processes {
+		if p.held().IsEmpty() {
+			continue
+		}
+		for _, inode := range socketInodes(filepath.Join(*procRoot, strconv.Itoa(p.PID), "fd")) {
+			for _, s := range byInode[inode] {
+				entries = append(entries, netEntry{
+					PID:       p.PID,
+					PPID:      p.PPID,
+					UID:       p.UID.Effective,
+					Command:   p.Command,
+					Protocol:  s.Proto,
+					Address:   s.Address,
+					Port:      s.Port,
+					Effective: names(p.Caps.Effective),
+					Permitted: names(p.Caps.Permitted),
+					Ambient:   names(p.Caps.Ambient),
+				})
+				effective = append(effective, p.Caps.Effective)
+			}
+		}
+	}
+
+	if *asJSON {
+		return writeJSON(stdout, entries)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PPID\tPID\tUSER\tCOMMAND\tPROTO\tADDRESS\tPORT\tEFFECTIVE")
+	for i, entry := range entries {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			entry.PPID, entry.PID, userName(entry.UID), entry.Command,
+			entry.Protocol, entry.Address, entry.Port, setText(effective[i]),
+		)
+	}
+	return tw.Flush()
+}
+
+// readSockets reads the listening TCP sockets, the unconnected UDP sockets,
+// and every raw and packet socket of <root>/net. The files describe the
+// network namespace of the reading process only.
+func readSockets(root string) ([]socket, error) {
+	var sockets []socket
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6", "raw", "raw6", "packet"} {
+		f, err := os.Open(filepath.Join(root, "net", proto))
+		if errors.Is(err, os.ErrNotExist) {
+			// IPv6 is disabled
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var found []socket
+		if proto == "packet" {
+			found, err = parsePacketSockets(f)
+		} else {
+			found, err = parseInetSockets(f, proto)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", proto, err)
+		}
+		sockets = append(sockets, found...)
+	}
+	return sockets, nil
+}
+
+// parseInetSockets parses /proc/net/{tcp,tcp6,udp,udp6,raw,raw6}
+func parseInetSockets(r io.Reader, proto string) ([]socket, error) {
+	var sockets []socket
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if strings.HasPrefix(proto, "tcp") && fields[3] != tcpListen {
+			continue
+		}
+		// a connected UDP socket is a client, not a service
+		if strings.HasPrefix(proto, "udp") && strings.Trim(fields[2], "0:") != "" {
+			continue
+		}
+
+		address, port, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if inode == 0 {
+			continue
+		}
+
+		sockets = append(sockets, socket{Proto: proto, Address: address, Port: port, Inode: inode})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddress parses the hexadecimal "address:port" of /proc/net,
+// where the address is printed as 32 bits words in host order
+func parseSocketAddress(value string) (string, int, error) {
+	idx := strings.IndexByte(value, ':')
+	if idx < 0 {
+		return "", 0, fmt.Errorf("invalid address %q", value)
+	}
+
+	raw, err := hex.DecodeString(value[:idx])
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %q", value)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		hostOrder.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+
+	port, err := strconv.ParseUint(value[idx+1:], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q", value)
+	}
+	return ip.String(), int(port), nil
+}
+
+// parsePacketSockets parses /proc/net/packet
+func parsePacketSockets(r io.Reader) ([]socket, error) {
+	var sockets []socket
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 {
+			continue
+		}
+
+		ethertype, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil {
+			return nil, err
+		}
+		inode, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		address := "*"
+		if fields[4] != "0" {
+			address = "ifindex " + fields[4]
+		}
+		sockets = append(sockets, socket{Proto: "packet", Address: address, Port: int(ethertype), Inode: inode})
+	}
+	return sockets, scanner.Err()
+}
+
+// socketInodes returns the inodes of the sockets in the fd directory of a
+// process, or nothing when the directory cannot be read
+func socketInodes(dir string) []uint64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var inodes []uint64
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join(dir, entry.Name()))
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+		if err == nil {
+			inodes = append(inodes, inode)
+		}
+	}
+
+	sort.Slice(inodes, func(i, j int) bool { return inodes[i] < inodes[j] })
+	return inodes
+}
@@ -0,0 +1,140 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+func TestParseSocketAddress(t *testing.T) {
+	type toCheck struct {
+		value   string
+		address string
+		port    int
+	}
+
+	checks := []toCheck{
+		{value: "0100007F:0035", address: "127.0.0.1", port: 53},
+		{value: "00000000:0050", address: "0.0.0.0", port: 80},
+		{value: "00000000000000000000000001000000:01BB", address: "::1", port: 443},
+		{value: "0000000000000000FFFF00000100007F:1F90", address: "127.0.0.1", port: 8080},
+	}
+
+	for _, check := range checks {
+		address, port, err := parseSocketAddress(check.value)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.value, err)
+			continue
+		}
+		if address != check.address || port != check.port {
+			t.Errorf("'%s' expected %s:%d but have %s:%d instead", check.value, check.address, check.port, address, port)
+		}
+	}
+
+	for _, invalid := range []string{"0100007F", "0100:0035", "0100007F:zz"} {
+		if _, _, err := parseSocketAddress(invalid); err == nil {
+			t.Errorf("'%s' expected an error", invalid)
+		}
+	}
+
+	// big endian hosts print the words in network order
+	defer func(order binary.ByteOrder) { hostOrder = order }(hostOrder)
+	hostOrder = binary.BigEndian
+	if address, port, err := parseSocketAddress("7F000001:0035"); err != nil || address != "127.0.0.1" || port != 53 {
+		t.Errorf("'7F000001:0035' expected 127.0.0.1:53 on a big endian host but have %s:%d (%v) instead", address, port, err)
+	}
+}
+
+func TestNetcap(t *testing.T) {
+	tree := gocapngtest.NewProcTree(t)
+	user := gocapng.IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+
+	tree.Add("httpd", gocapng.ProcessState{
+		PID: 10, PPID: 1, UID: user, GID: user,
+		Caps: gocapng.Capabilities{
+			Effective: gocapng.NewCapSet(gocapng.CAPNetBindService),
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetBindService),
+		},
+	})
+	tree.Add("ping", gocapng.ProcessState{
+		PID: 11, PPID: 1, UID: user, GID: user,
+		Caps: gocapng.Capabilities{Effective: gocapng.NewCapSet(gocapng.CAPNetRaw), Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw)},
+	})
+	tree.Add("client", gocapng.ProcessState{PID: 12, PPID: 1, UID: user, GID: user})
+
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	writeNet := func(name, content string) {
+		if err := os.MkdirAll(filepath.Join(tree.Root, "net"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tree.Root, "net", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeNet("tcp", header+
+		"   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 100 1 0 100 0 0 10 0\n"+
+		"   1: 0100007F:C350 0100007F:0050 01 00000000:00000000 00:00000000 00000000  1000        0 101 1 0 100 0 0 10 0\n"+
+		"   2: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 102 1 0 100 0 0 10 0\n")
+	writeNet("udp", header+
+		"   3: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000  1000        0 103 2 0 0\n"+
+		"   4: 0100007F:D431 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 104 2 0 0\n")
+	writeNet("raw", header+
+		"   1: 00000000:0001 00000000:0000 07 00000000:00000000 00:00000000 00000000  1000        0 200 2 0 0\n")
+	writeNet("packet", "sk               RefCnt Type Proto  Iface R Rmem   User   Inode\n"+
+		"0000000000000000 3      3    0003   2     1 0      1000   201\n")
+
+	link := func(pid int, fd, target string) {
+		dir := filepath.Join(tree.Dir(pid), "fd")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link(10, "0", "/dev/null")
+	link(10, "3", "socket:[100]")
+	link(10, "4", "socket:[101]")
+	link(10, "5", "socket:[103]")
+	link(10, "6", "socket:[104]")
+	link(11, "3", "socket:[200]")
+	link(11, "4", "socket:[201]")
+	link(12, "3", "socket:[102]")
+
+	var b bytes.Buffer
+	if err := runNetcap([]string{"-proc", tree.Root, "-json"}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var entries []netEntry
+	if err := json.Unmarshal(b.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []netEntry{
+		{PID: 10, Command: "httpd", Protocol: "tcp", Address: "0.0.0.0", Port: 80},
+		{PID: 10, Command: "httpd", Protocol: "udp", Address: "0.0.0.0", Port: 53},
+		{PID: 11, Command: "ping", Protocol: "raw", Address: "0.0.0.0", Port: 1},
+		{PID: 11, Command: "ping", Protocol: "packet", Address: "ifindex 2", Port: 3},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d sockets, have %s", len(expected), b.String())
+	}
+	for i, entry := range entries {
+		e := expected[i]
+		if entry.PID != e.PID || entry.Command != e.Command || entry.Protocol != e.Protocol ||
+			entry.Address != e.Address || entry.Port != e.Port {
+			t.Errorf("Expected %+v but have %+v instead", e, entry)
+		}
+	}
+	if len(entries[0].Effective) != 1 || entries[0].Effective[0] != "net_bind_service" {
+		t.Errorf("Expected net_bind_service, have %v", entries[0].Effective)
+	}
+}
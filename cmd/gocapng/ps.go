@@ -0,0 +1,306 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/ik5/gocapng"
+)
+
+// process is a process read from /proc
+type process struct {
+	gocapng.ProcessState
+	Command string
+
+	parent   *process
+	children []*process
+}
+
+// held returns every capability the process can use without execve
+func (p *process) held() gocapng.CapSet {
+	return p.Caps.Effective.Union(p.Caps.Permitted).Union(p.Caps.Ambient)
+}
+
+// psEntry is the JSON layout of a process
+type psEntry struct {
+	PID         int        `json:"pid"`
+	PPID        int        `json:"ppid"`
+	UID         int        `json:"uid"`
+	GID         int        `json:"gid"`
+	Command     string     `json:"command"`
+	Effective   []string   `json:"effective"`
+	Permitted   []string   `json:"permitted"`
+	Inheritable []string   `json:"inheritable"`
+	Bounding    []string   `json:"bounding"`
+	Ambient     []string   `json:"ambient"`
+	NoNewPrivs  bool       `json:"no_new_privs"`
+	Gained      []string   `json:"gained,omitempty"`
+	Dropped     []string   `json:"dropped,omitempty"`
+	Children    []*psEntry `json:"children,omitempty"`
+}
+
+func runPS(args []string, stdout io.Writer) error {
+	flags := newFlagSet("ps", "[-a] [-cap names] [-tree] [-json]")
+	all := flags.Bool("a", false, "include root processes holding every capability")
+	capList := flags.String("cap", "", "comma separated capabilities a process must hold")
+	tree := flags.Bool("tree", false, "show the processes as a tree, with the capabilities gained and dropped from the parent")
+	asJSON := flags.Bool("json", false, "write JSON instead of text")
+	procRoot := flags.String("proc", "/proc", "`directory` of the proc file system")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	filter, err := parseCapabilities(*capList)
+	if err != nil {
+		return err
+	}
+
+	processes, err := readProcesses(*procRoot)
+	if err != nil {
+		return err
+	}
+
+	full := gocapng.FullCapSet()
+	selected := func(p *process) bool {
+		if p.held().Union(p.Caps.Inheritable).IsEmpty() {
+			return false
+		}
+		if !*all && p.UID.Effective == 0 && p.Caps.Permitted == full {
+			return false
+		}
+		return filter.IsSubset(p.held())
+	}
+
+	if *tree {
+		roots := buildTree(processes, selected)
+		if *asJSON {
+			entries := make([]*psEntry, 0, len(roots))
+			for _, root := range roots {
+				entries = append(entries, treeEntry(root))
+			}
+			return writeJSON(stdout, entries)
+		}
+		for _, root := range roots {
+			writeTree(stdout, root, "", "")
+		}
+		return nil
+	}
+
+	var shown []*process
+	for _, p := range processes {
+		if selected(p) {
+			shown = append(shown, p)
+		}
+	}
+
+	if *asJSON {
+		entries := make([]*psEntry, 0, len(shown))
+		for _, p := range shown {
+			entries = append(entries, newPSEntry(p))
+		}
+		return writeJSON(stdout, entries)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PPID\tPID\tUSER\tCOMMAND\tEFFECTIVE\tPERMITTED\tAMBIENT")
+	for _, p := range shown {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			p.PPID, p.PID, userName(p.UID.Effective), p.Command,
+			setText(p.Caps.Effective), setText(p.Caps.Permitted), setText(p.Caps.Ambient),
+		)
+	}
+	return tw.Flush()
+}
+
+// readProcesses reads every process of the proc file system at root, ordered
+// by pid. Processes that exit while reading are skipped.
+func readProcesses(root string) ([]*process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []*process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		state, err := gocapng.ReadProcessStateAt(root, pid)
+		if processGone(err) || errors.Is(err, os.ErrPermission) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pid %d: %w", pid, err)
+		}
+
+		p := &process{ProcessState: state, Command: "?"}
+		if comm, err := os.ReadFile(filepath.Join(root, entry.Name(), "comm")); err == nil {
+			p.Command = strings.TrimSpace(string(comm))
+		}
+		processes = append(processes, p)
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PID < processes[j].PID
+	})
+	return processes, nil
+}
+
+// processGone returns true when err tells that the process exited while its
+// status was read: the file is gone, the kernel returns ESRCH, or the status
+// is empty or cut short
+func processGone(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ESRCH) ||
+		errors.Is(err, gocapng.ErrInvalidProcessStatus)
+}
+
+// buildTree links processes to their parents, and returns the roots of the
+// trees holding every selected process together with its ancestors
+func buildTree(processes []*process, selected func(p *process) bool) []*process {
+	byPID := make(map[int]*process, len(processes))
+	for _, p := range processes {
+		byPID[p.PID] = p
+	}
+
+	keep := make(map[*process]bool)
+	for _, p := range processes {
+		if !selected(p) {
+			continue
+		}
+		for node := p; node != nil && !keep[node]; node = byPID[node.PPID] {
+			keep[node] = true
+			if node.PPID == node.PID {
+				break
+			}
+		}
+	}
+
+	var roots []*process
+	for _, p := range processes {
+		if !keep[p] {
+			continue
+		}
+		parent, ok := byPID[p.PPID]
+		if !ok || parent == p {
+			roots = append(roots, p)
+			continue
+		}
+		p.parent = parent
+		parent.children = append(parent.children, p)
+	}
+	return roots
+}
+
+// flow returns the permitted capabilities p gained and dropped compared to
+// its parent
+func flow(p *process) (gained, dropped gocapng.CapSet) {
+	if p.parent == nil {
+		return 0, 0
+	}
+	return p.Caps.Permitted.Difference(p.parent.Caps.Permitted),
+		p.parent.Caps.Permitted.Difference(p.Caps.Permitted)
+}
+
+func writeTree(w io.Writer, p *process, prefix, childPrefix string) {
+	line := fmt.Sprintf("%s%d %s (%s) %s", prefix, p.PID, p.Command, userName(p.UID.Effective), setText(p.Caps.Effective))
+
+	gained, dropped := flow(p)
+	var changes []string
+	if !gained.IsEmpty() {
+		changes = append(changes, "gains "+gained.String())
+	}
+	if !dropped.IsEmpty() {
+		changes = append(changes, "drops "+setText(dropped))
+	}
+	if len(changes) > 0 {
+		line += " [" + strings.Join(changes, "; ") + "]"
+	}
+	fmt.Fprintln(w, line)
+
+	for i, child := range p.children {
+		if i == len(p.children)-1 {
+			writeTree(w, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			writeTree(w, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+func newPSEntry(p *process) *psEntry {
+	return &psEntry{
+		PID:         p.PID,
+		PPID:        p.PPID,
+		UID:         p.UID.Effective,
+		GID:         p.GID.Effective,
+		Command:     p.Command,
+		Effective:   names(p.Caps.Effective),
+		Permitted:   names(p.Caps.Permitted),
+		Inheritable: names(p.Caps.Inheritable),
+		Bounding:    names(p.Caps.Bounding),
+		Ambient:     names(p.Caps.Ambient),
+		NoNewPrivs:  p.NoNewPrivs,
+	}
+}
+
+func treeEntry(p *process) *psEntry {
+	entry := newPSEntry(p)
+	gained, dropped := flow(p)
+	if !gained.IsEmpty() {
+		entry.Gained = names(gained)
+	}
+	if !dropped.IsEmpty() {
+		entry.Dropped = names(dropped)
+	}
+	for _, child := range p.children {
+		entry.Children = append(entry.Children, treeEntry(child))
+	}
+	return entry
+}
+
+// names returns the names of set, never nil so that JSON holds an empty list
+func names(set gocapng.CapSet) []string {
+	result := []string{}
+	for _, capability := range set.List() {
+		result = append(result, capability.String())
+	}
+	return result
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+var userNames = map[int]string{}
+
+// userName returns the name of uid, or uid itself when it has no name
+func userName(uid int) string {
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
@@ -0,0 +1,146 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+	"github.com/ik5/gocapng/gocapngtest"
+)
+
+// psFixture builds a proc tree where root holds every capability, a daemon
+// keeps net_bind_service and a child of the daemon gains net_raw
+func psFixture(t *testing.T) string {
+	full := gocapng.FullCapSet()
+	root := gocapng.IDs{}
+	user := gocapng.IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+
+	tree := gocapngtest.NewProcTree(t)
+	tree.Add("init", gocapng.ProcessState{
+		PID: 1, UID: root, GID: root,
+		Caps: gocapng.Capabilities{Effective: full, Permitted: full, Bounding: full},
+	})
+	tree.Add("daemon", gocapng.ProcessState{
+		PID: 10, PPID: 1, UID: user, GID: user,
+		Caps: gocapng.Capabilities{
+			Effective: gocapng.NewCapSet(gocapng.CAPNetBindService),
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetBindService),
+			Bounding:  full,
+		},
+	})
+	tree.Add("ping", gocapng.ProcessState{
+		PID: 11, PPID: 10, UID: user, GID: user,
+		Caps: gocapng.Capabilities{
+			Effective: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Bounding:  full,
+		},
+	})
+	tree.Add("shell", gocapng.ProcessState{PID: 12, PPID: 1, UID: user, GID: user, Caps: gocapng.Capabilities{Bounding: full}})
+	return tree.Root
+}
+
+func TestPS(t *testing.T) {
+	proc := psFixture(t)
+
+	type toCheck struct {
+		name     string
+		args     []string
+		expected []int
+	}
+
+	checks := []toCheck{
+		{name: "default", args: nil, expected: []int{10, 11}},
+		{name: "all", args: []string{"-a"}, expected: []int{1, 10, 11}},
+		{name: "filter", args: []string{"-cap", "cap_net_raw"}, expected: []int{11}},
+		{name: "filter root", args: []string{"-a", "-cap", "sys_admin,kill"}, expected: []int{1}},
+	}
+
+	for _, check := range checks {
+		var b bytes.Buffer
+		args := append([]string{"-proc", proc, "-json"}, check.args...)
+		if err := runPS(args, &b); err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+
+		var entries []psEntry
+		if err := json.Unmarshal(b.Bytes(), &entries); err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		var pids []int
+		for _, entry := range entries {
+			pids = append(pids, entry.PID)
+		}
+		if len(pids) != len(check.expected) {
+			t.Errorf("'%s' expected %v but have %v instead", check.name, check.expected, pids)
+			continue
+		}
+		for i := range pids {
+			if pids[i] != check.expected[i] {
+				t.Errorf("'%s' expected %v but have %v instead", check.name, check.expected, pids)
+				break
+			}
+		}
+	}
+
+	if err := runPS([]string{"-proc", proc, "-cap", "unknown"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected an error for an unknown capability")
+	}
+}
+
+func TestReadProcessesExited(t *testing.T) {
+	tree := gocapngtest.NewProcTree(t)
+	tree.Add("init", gocapng.ProcessState{PID: 1})
+	// processes exiting while their status is read
+	tree.WriteFile(20, "status", "")
+	tree.WriteFile(21, "status", "Name:\tgone\nPid:\t21\n")
+
+	processes, err := readProcesses(tree.Root)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(processes) != 1 || processes[0].PID != 1 {
+		t.Errorf("Expected only pid 1, have %d processes", len(processes))
+	}
+}
+
+func TestPSTree(t *testing.T) {
+	proc := psFixture(t)
+
+	var b bytes.Buffer
+	if err := runPS([]string{"-proc", proc, "-tree", "-cap", "net_raw"}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected init, daemon and ping, have\n%s\ninstead", b.String())
+	}
+	if !strings.HasPrefix(lines[0], "1 init (root) full") {
+		t.Errorf("Unexpected root line '%s'", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "    └── 11 ping ") || !strings.HasSuffix(lines[2], "[gains net_raw; drops net_bind_service]") {
+		t.Errorf("Unexpected child line '%s'", lines[2])
+	}
+
+	b.Reset()
+	if err := runPS([]string{"-proc", proc, "-tree", "-json"}, &b); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var roots []psEntry
+	if err := json.Unmarshal(b.Bytes(), &roots); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(roots) != 1 || len(roots[0].Children) != 1 {
+		t.Fatalf("Expected init with the daemon as single child, have %s", b.String())
+	}
+	daemon := roots[0].Children[0]
+	if daemon.PID != 10 || len(daemon.Gained) != 0 || len(daemon.Children) != 1 {
+		t.Errorf("Unexpected daemon entry %+v", daemon)
+	}
+}
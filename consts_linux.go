@@ -42,6 +42,20 @@ const (
 	PrintBuffer
 )
 
+// Layouts of WriteCapabilities
+const (
+	// One line per set with the names of the capabilities, in the layout of
+	// PrintCapsText
+	FormatText Format = iota
+	// One line per set with the two 32 bits words of the set, in the layout
+	// of PrintCapsNumberic
+	FormatNumeric
+	// A JSON object mapping every set to the names of its capabilities
+	FormatJSON
+	// A table with one row per capability and one column per set
+	FormatTable
+)
+
 // Supported flags
 const (
 	// Simply change uid and retain specified capabilities and that's all.
@@ -57,6 +71,42 @@ const (
 	FlagsClearAmbient Flags = 8
 )
 
+// Securebits flags from /usr/include/linux/securebits.h
+const (
+	// Root (uid 0) gains no capabilities on execve and set*uid.
+	SecureNoRoot Securebits = 1 << iota
+	// SecureNoRoot can no longer be changed.
+	SecureNoRootLocked
+	// Changing uids to and from 0 does not adjust the capabilities sets.
+	SecureNoSetUIDFixup
+	// SecureNoSetUIDFixup can no longer be changed.
+	SecureNoSetUIDFixupLocked
+	// Permitted capabilities are kept when all uids stop being 0.
+	SecureKeepCaps
+	// SecureKeepCaps can no longer be changed.
+	SecureKeepCapsLocked
+	// Ambient capabilities can no longer be raised.
+	SecureNoCapAmbientRaise
+	// SecureNoCapAmbientRaise can no longer be changed.
+	SecureNoCapAmbientRaiseLocked
+)
+
+// Risk tiers
+const (
+	// The capability is not known to this package
+	RiskUnknown RiskTier = iota
+	// Narrow capabilities that cannot be used to gain other privileges
+	RiskLow
+	// Capabilities that affect other processes or the system, without a
+	// known way to gain full root
+	RiskMedium
+	// Broad capabilities that expose sensitive data or the system
+	// configuration
+	RiskHigh
+	// Capabilities that are known to lead to full root
+	RiskCritical
+)
+
 // UnsetRootID for namespace root id
 const UnsetRootID int = -1
 
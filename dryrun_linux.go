@@ -0,0 +1,218 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// DryRun is the predicted outcome of an Apply or ChangeID call, computed
+// from the state of the process and the state stored in libcap-ng without
+// changing either of them.
+type DryRun struct {
+	// Operation describes the call, for example "Apply(select_all)"
+	Operation string
+	Before    ProcessState
+	After     ProcessState
+
+	// Errors holds the errors (from errors.go, possibly wrapped in a
+	// RequirementError) the call is expected to hit given the current
+	// privileges, in the order they will be hit. ChangeID stops at the first
+	// one, Apply keeps going with the next select.
+	Errors []error
+}
+
+// Changed returns true if the call is expected to change the process
+func (d DryRun) Changed() bool {
+	return d.Before != d.After
+}
+
+// String explains the dry run in a human readable way
+func (d DryRun) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", d.Operation)
+	for _, t := range []Type{
+		TypeEffective, TypePermitted, TypeInheritable, TypeBoundingSet, TypeAmbient,
+	} {
+		writeDryRunLine(&b, t.String(), d.Before.Caps.Get(t), d.After.Caps.Get(t))
+	}
+	writeDryRunLine(&b, "uid", d.Before.UID, d.After.UID)
+	writeDryRunLine(&b, "gid", d.Before.GID, d.After.GID)
+	writeDryRunLine(&b, "securebits", d.Before.Securebits, d.After.Securebits)
+
+	if len(d.Errors) == 0 {
+		b.WriteString("expected errors: none\n")
+		return b.String()
+	}
+
+	b.WriteString("expected errors:\n")
+	for _, err := range d.Errors {
+		fmt.Fprintf(&b, "  - %s\n", err)
+	}
+	return b.String()
+}
+
+func writeDryRunLine(b *strings.Builder, name string, before, after interface{}) {
+	if before == after {
+		fmt.Fprintf(b, "  %-13s %v (unchanged)\n", name+":", before)
+		return
+	}
+	fmt.Fprintf(b, "  %-13s %v -> %v\n", name+":", before, after)
+}
+
+// PredictApply returns what Apply would do to a process in the before state,
+// with pending as the capabilities stored in libcap-ng, following the steps
+// of capng_apply. It is the model behind DryRunApply.
+func PredictApply(before ProcessState, pending Capabilities, set Select) DryRun {
+	run := DryRun{
+		Operation: fmt.Sprintf("Apply(%s)", set),
+		Before:    before,
+		After:     before,
+	}
+	after := &run.After
+
+	if set&SelectBounds != 0 {
+		if !after.Caps.Effective.Has(CAPSetPCap) {
+			run.Errors = append(run.Errors, requirementError(OpDropBoundingSet, ErrSelectBoundsCAPSetPCap))
+		} else {
+			// Bounding set capabilities can only be dropped, never added back
+			after.Caps.Bounding = after.Caps.Bounding.Intersect(pending.Bounding)
+		}
+	}
+
+	if set&SelectCaps != 0 {
+		if capsetAllowed(after.Caps, pending) {
+			after.Caps = capset(after.Caps, pending)
+		} else {
+			run.Errors = append(run.Errors, ErrSelectCapsCapsetSyscall)
+		}
+	}
+
+	if set&SelectAmbient != 0 {
+		after.Caps.Ambient = 0
+		for _, capability := range pending.Ambient.List() {
+			if !ambientRaiseAllowed(after.Caps, after.Securebits, capability) {
+				run.Errors = append(run.Errors, ErrSelectAmbientProcessCapabilitiesSetting)
+				break
+			}
+			after.Caps.Ambient = after.Caps.Ambient.Add(capability)
+		}
+	}
+
+	return run
+}
+
+// PredictChangeID returns what ChangeID would do to a process in the before
+// state, with pending as the capabilities stored in libcap-ng, following the
+// steps of capng_change_id. It is the model behind DryRunChangeID.
+func PredictChangeID(before ProcessState, pending Capabilities, uid, gid int, flag Flags) DryRun {
+	run := DryRun{
+		Operation: fmt.Sprintf("ChangeID(%d, %d, %s)", uid, gid, flagsString(flag)),
+		Before:    before,
+		After:     before,
+	}
+	after := &run.After
+	fail := func(err error) DryRun {
+		run.Errors = append(run.Errors, err)
+		return run
+	}
+
+	// CAPSetUID, CAPSetGID and CAPSetPCap are added for the duration of the
+	// call when they are needed and not already requested.
+	intermediate := pending
+	var temporary CapSet
+	if flag&FlagsClearBounding != 0 && !pending.Effective.Has(CAPSetPCap) {
+		temporary = temporary.Add(CAPSetPCap)
+	}
+	if gid != -1 && !pending.Effective.Has(CAPSetGID) {
+		temporary = temporary.Add(CAPSetGID)
+	}
+	if uid != -1 && !pending.Effective.Has(CAPSetUID) {
+		temporary = temporary.Add(CAPSetUID)
+	}
+	intermediate.Effective = intermediate.Effective.Union(temporary)
+	intermediate.Permitted = intermediate.Permitted.Union(temporary)
+
+	if after.Securebits&SecureKeepCapsLocked != 0 {
+		return fail(ErrFailureRequestingCapabilitiesUidChange)
+	}
+	after.Securebits |= SecureKeepCaps
+
+	if !capsetAllowed(after.Caps, intermediate) {
+		return fail(ErrApplyingIntermediateCapabilitiesFailed)
+	}
+	after.Caps = capset(after.Caps, intermediate)
+
+	if flag&FlagsClearBounding != 0 {
+		if !after.Caps.Effective.Has(CAPSetPCap) {
+			return fail(requirementError(OpDropBoundingSet, ErrClearingBoundingSet))
+		}
+		after.Caps.Bounding = 0
+	}
+
+	if gid != -1 {
+		if !after.Caps.Effective.Has(CAPSetGID) && !idIn(gid, after.GID) {
+			return fail(requirementError(OpChangeGID, ErrChangingGIDFailed))
+		}
+		after.GID = IDs{Real: gid, Effective: gid, Saved: gid, FS: gid}
+	}
+
+	if flag&FlagsInitSuppGrp != 0 && uid != -1 {
+		if _, err := user.LookupId(strconv.Itoa(uid)); err != nil {
+			return fail(ErrInitializedSupplementalGroups)
+		}
+		if !after.Caps.Effective.Has(CAPSetGID) {
+			return fail(requirementError(OpSetGroups, ErrDroppingSupplementalGroupsFailed))
+		}
+	}
+
+	if flag&FlagsDropSuppGrp != 0 && gid != -1 && !after.Caps.Effective.Has(CAPSetGID) {
+		return fail(requirementError(OpSetGroups, ErrDroppingSupplementalGroupsFailed))
+	}
+
+	if uid != -1 {
+		next := IDs{Real: uid, Effective: uid, Saved: uid, FS: uid}
+		caps, err := SimulateSetUID(after.Caps, after.Securebits, after.UID, next)
+		if err != nil {
+			return fail(err)
+		}
+		after.Caps, after.UID = caps, next
+	}
+
+	after.Securebits &^= SecureKeepCaps
+
+	final := pending
+	final.Effective = final.Effective.Difference(temporary)
+	final.Permitted = final.Permitted.Difference(temporary)
+	if flag&FlagsClearAmbient != 0 {
+		final.Ambient = 0
+	}
+	if !capsetAllowed(after.Caps, final) {
+		return fail(ErrDroppingCAPSETPCAP)
+	}
+	after.Caps = capset(after.Caps, final)
+	if flag&FlagsClearAmbient != 0 {
+		after.Caps.Ambient = 0
+	}
+
+	return run
+}
+
+// flagsString returns the names of all flags or'ed into flag
+func flagsString(flag Flags) string {
+	if flag == FlagsNoFlag {
+		return flag.String()
+	}
+
+	names := []string{}
+	for f := FlagsDropSuppGrp; f <= FlagsClearAmbient; f <<= 1 {
+		if flag&f != 0 {
+			names = append(names, f.String())
+		}
+	}
+	return strings.Join(names, "|")
+}
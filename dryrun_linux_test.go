@@ -0,0 +1,121 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"errors"
+	"testing"
+)
+
+func rootState() ProcessState {
+	all := CapSet(1<<(CAPLastCap+1) - 1)
+	return ProcessState{
+		Caps: Capabilities{Effective: all, Permitted: all, Bounding: all},
+	}
+}
+
+func TestPredictApply(t *testing.T) {
+	unprivileged := ProcessState{
+		UID:  IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000},
+		Caps: Capabilities{Bounding: rootState().Caps.Bounding},
+	}
+	pending := Capabilities{
+		Effective: NewCapSet(CAPNetBindService),
+		Permitted: NewCapSet(CAPNetBindService),
+	}
+
+	toCheck := []struct {
+		name     string
+		before   ProcessState
+		pending  Capabilities
+		set      Select
+		expected []error
+	}{
+		{
+			name:    "root drop",
+			before:  rootState(),
+			pending: pending,
+			set:     SelectAll,
+		},
+		{
+			name:     "unprivileged raise",
+			before:   unprivileged,
+			pending:  pending,
+			set:      SelectBoth,
+			expected: []error{ErrSelectBoundsCAPSetPCap, ErrSelectCapsCapsetSyscall},
+		},
+		{
+			name:   "ambient without inheritable",
+			before: rootState(),
+			pending: Capabilities{
+				Permitted: NewCapSet(CAPNetRaw),
+				Ambient:   NewCapSet(CAPNetRaw),
+			},
+			set:      SelectAmbient,
+			expected: []error{ErrSelectAmbientProcessCapabilitiesSetting},
+		},
+	}
+
+	for _, check := range toCheck {
+		run := PredictApply(check.before, check.pending, check.set)
+		if len(run.Errors) != len(check.expected) {
+			t.Errorf("'%s' expected errors %v, got %v", check.name, check.expected, run.Errors)
+			continue
+		}
+		for i, err := range check.expected {
+			if !errors.Is(run.Errors[i], err) {
+				t.Errorf("'%s' expected error '%s', got '%s'", check.name, err, run.Errors[i])
+			}
+		}
+	}
+
+	run := PredictApply(rootState(), pending, SelectAll)
+	if run.After.Caps.Effective != pending.Effective || run.After.Caps.Bounding != 0 {
+		t.Errorf("Unexpected state after apply:\n%s", run)
+	}
+
+	if !run.Changed() {
+		t.Error("Expected apply to change the process")
+	}
+}
+
+func TestPredictChangeID(t *testing.T) {
+	pending := Capabilities{
+		Effective: NewCapSet(CAPNetBindService),
+		Permitted: NewCapSet(CAPNetBindService),
+	}
+
+	run := PredictChangeID(rootState(), pending, 1000, 1000, FlagsClearBounding|FlagsDropSuppGrp)
+	if len(run.Errors) != 0 {
+		t.Fatalf("Expected no errors, got %v", run.Errors)
+	}
+
+	expected := IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+	if run.After.UID != expected || run.After.GID != expected {
+		t.Errorf("Expected uid and gid %s, got %s and %s", expected, run.After.UID, run.After.GID)
+	}
+
+	if run.After.Caps.Permitted != pending.Permitted || run.After.Caps.Effective != pending.Effective {
+		t.Errorf("Expected %s to be kept, got %s", pending.Permitted, run.After.Caps.Permitted)
+	}
+
+	if run.After.Caps.Bounding != 0 {
+		t.Errorf("Expected empty bounding set, got %s", run.After.Caps.Bounding)
+	}
+
+	unprivileged := ProcessState{
+		UID: IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000},
+		GID: IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000},
+	}
+	run = PredictChangeID(unprivileged, Capabilities{}, 0, -1, FlagsNoFlag)
+	if len(run.Errors) != 1 || !errors.Is(run.Errors[0], ErrApplyingIntermediateCapabilitiesFailed) {
+		t.Errorf("Expected %s, got %v", ErrApplyingIntermediateCapabilitiesFailed, run.Errors)
+	}
+
+	locked := rootState()
+	locked.Securebits = SecureKeepCapsLocked
+	run = PredictChangeID(locked, pending, 1000, 1000, FlagsNoFlag)
+	if len(run.Errors) != 1 || !errors.Is(run.Errors[0], ErrFailureRequestingCapabilitiesUidChange) {
+		t.Errorf("Expected %s, got %v", ErrFailureRequestingCapabilitiesUidChange, run.Errors)
+	}
+}
@@ -25,4 +25,20 @@ var (
 	ErrFDIsNotRegularFile                           = errors.New("fd is not a regular file")
 	ErrNonRootNamespaceIDUsedForRootID              = errors.New("non-root namespace id is being used for rootid")
 	ErrCapabilityNotFound                           = errors.New("Capability not found")
+	ErrExecFileCapabilitiesNotGranted               = errors.New("file effective bit is set but not all file permitted capabilities can be granted")
+	ErrInvalidFileCaps                              = errors.New("invalid security.capability extended attribute")
+	ErrInvalidFileCapsText                          = errors.New("invalid file capabilities text")
+	ErrInvalidProcessStatus                         = errors.New("invalid /proc/<pid>/status content")
+	ErrUnknownOperation                             = errors.New("unknown operation")
+	ErrMissingCapabilities                          = errors.New("missing capabilities")
+	ErrForbiddenCapabilities                        = errors.New("forbidden capabilities are present")
+	ErrUnknownSecurebit                             = errors.New("unknown securebit")
+	ErrInvalidNumericCaps                           = errors.New("invalid libcap-ng numeric capabilities")
+	ErrUnknownFormat                                = errors.New("unknown capabilities format")
+	ErrInvalidPolicy                                = errors.New("invalid capability policy")
+	ErrUnsupportedPolicyFormat                      = errors.New("unsupported capability policy format")
+	ErrSettingSecurebitsFailed                      = errors.New("setting securebits failed")
+	ErrAllThreadsUnsupported                        = errors.New("changing every thread needs a program built with CGO_ENABLED=0")
+	ErrRatchetEngaged                               = errors.New("ratchet engaged, capabilities can only be dropped")
+	ErrPolicyNotEnforced                            = errors.New("process does not match the capability policy after enforcing it")
 )
@@ -0,0 +1,46 @@
+package gocapng
+
+import "fmt"
+
+// EscalationPath is a known way to gain full root from a capability
+type EscalationPath struct {
+	Capability Capability
+	// Info is the metadata of Capability, see Capability.Info
+	Info CapabilityInfo
+	// Explanation describes how the capability is used to gain root
+	Explanation string
+}
+
+func (p EscalationPath) String() string {
+	return fmt.Sprintf("%s: %s", p.Capability, p.Explanation)
+}
+
+// AnalyzeEscalation returns the known paths from set to full root, ordered by
+// capability. Combinations of capabilities are not listed: the known ones,
+// such as setuid with setgid, all hold a capability leading to root alone.
+//
+// A set without any path is not known to be root equivalent, which is not a
+// proof that it is safe: see the Risk of every capability.
+func AnalyzeEscalation(set CapSet) []EscalationPath {
+	var paths []EscalationPath
+
+	for _, capability := range set.List() {
+		info := capability.Info()
+		if !info.RootEquivalent {
+			continue
+		}
+		paths = append(paths, EscalationPath{
+			Capability:  capability,
+			Info:        info,
+			Explanation: info.Escalation,
+		})
+	}
+
+	return paths
+}
+
+// IsRootEquivalent returns true if set holds at least one capability that is
+// known to lead to full root
+func IsRootEquivalent(set CapSet) bool {
+	return len(AnalyzeEscalation(set)) > 0
+}
@@ -0,0 +1,60 @@
+package gocapng
+
+import "testing"
+
+func TestAnalyzeEscalation(t *testing.T) {
+	toCheck := []struct {
+		name     string
+		set      CapSet
+		expected []Capability
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "net_bind_service,net_raw",
+			set:  NewCapSet(CAPNetBindService, CAPNetRaw),
+		},
+		{
+			name:     "setuid",
+			set:      NewCapSet(CAPSetUID),
+			expected: []Capability{CAPSetUID},
+		},
+		{
+			name:     "sys_ptrace,kill,sys_module",
+			set:      NewCapSet(CAPSysPTrace, CAPKill, CAPSysModule),
+			expected: []Capability{CAPSysModule, CAPSysPTrace},
+		},
+		{
+			name:     "setuid,setgid",
+			set:      NewCapSet(CAPSetUID, CAPSetGID),
+			expected: []Capability{CAPSetGID, CAPSetUID},
+		},
+		{
+			name: "dac_read_search",
+			set:  NewCapSet(CAPDACReadSearch),
+		},
+	}
+
+	for _, check := range toCheck {
+		paths := AnalyzeEscalation(check.set)
+		if len(paths) != len(check.expected) {
+			t.Errorf(
+				"'%s' expected to have %d paths but have %d instead",
+				check.name, len(check.expected), len(paths),
+			)
+			continue
+		}
+		for i, path := range paths {
+			if path.Capability != check.expected[i] || path.Explanation == "" {
+				t.Errorf(
+					"'%s' expected path %d to be '%s' but have '%s' instead",
+					check.name, i, check.expected[i], path,
+				)
+			}
+		}
+		if IsRootEquivalent(check.set) != (len(check.expected) > 0) {
+			t.Errorf("'%s' has an unexpected IsRootEquivalent", check.name)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// fileCapsXattr is the extended attribute holding the file capabilities
+const fileCapsXattr = "security.capability"
+
+// vfs_cap_data layout from /usr/include/linux/capability.h
+const (
+	vfsCapRevisionMask   = 0xFF000000
+	vfsCapRevisionShift  = 24
+	vfsCapFlagsEffective = 0x000001
+
+	vfsCapRevision1 = 1
+	vfsCapRevision2 = 2
+	vfsCapRevision3 = 3
+
+	vfsCapSizeRevision1 = 4 + 1*8
+	vfsCapSizeRevision2 = 4 + 2*8
+	vfsCapSizeRevision3 = 4 + 2*8 + 4
+)
+
+// ReadFileCaps reads the capabilities of the file at path from its extended
+// attributes, following symbolic links the same way execve does.
+//
+// A file without capabilities returns a FileCaps with Version 0 and no error.
+func ReadFileCaps(path string) (FileCaps, error) {
+	buf := make([]byte, vfsCapSizeRevision3)
+	n, err := syscall.Getxattr(path, fileCapsXattr, buf)
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return FileCaps{RootID: UnsetRootID}, nil
+	}
+	if err != nil {
+		return FileCaps{}, err
+	}
+	return ParseFileCaps(buf[:n])
+}
+
+// ParseFileCaps parses the raw content of the security.capability extended
+// attribute.
+func ParseFileCaps(data []byte) (FileCaps, error) {
+	if len(data) < 4 {
+		return FileCaps{}, ErrInvalidFileCaps
+	}
+
+	magic := binary.LittleEndian.Uint32(data)
+	result := FileCaps{
+		Version:   int((magic & vfsCapRevisionMask) >> vfsCapRevisionShift),
+		Effective: magic&vfsCapFlagsEffective != 0,
+		RootID:    UnsetRootID,
+	}
+
+	words := 2
+	switch {
+	case result.Version == vfsCapRevision1 && len(data) == vfsCapSizeRevision1:
+		words = 1
+	case result.Version == vfsCapRevision2 && len(data) == vfsCapSizeRevision2:
+	case result.Version == vfsCapRevision3 && len(data) == vfsCapSizeRevision3:
+		result.RootID = int(binary.LittleEndian.Uint32(data[vfsCapSizeRevision2:]))
+	default:
+		return FileCaps{}, ErrInvalidFileCaps
+	}
+
+	for i := 0; i < words; i++ {
+		offset := 4 + i*8
+		shift := uint(32 * i)
+		result.Permitted |= CapSet(binary.LittleEndian.Uint32(data[offset:])) << shift
+		result.Inheritable |= CapSet(binary.LittleEndian.Uint32(data[offset+4:])) << shift
+	}
+
+	return result, nil
+}
+
+// MarshalFileCaps returns the raw content of the security.capability extended
+// attribute holding caps, the reverse of ParseFileCaps.
+//
+// A Version of 0 picks revision 3 when caps has a RootID, and revision 2
+// otherwise.
+func MarshalFileCaps(caps FileCaps) []byte {
+	version := caps.Version
+	if version == 0 {
+		version = vfsCapRevision2
+		if caps.RootID != UnsetRootID {
+			version = vfsCapRevision3
+		}
+	}
+
+	words := 2
+	size := vfsCapSizeRevision2
+	switch version {
+	case vfsCapRevision1:
+		words = 1
+		size = vfsCapSizeRevision1
+	case vfsCapRevision3:
+		size = vfsCapSizeRevision3
+	}
+
+	magic := uint32(version) << vfsCapRevisionShift
+	if caps.Effective {
+		magic |= vfsCapFlagsEffective
+	}
+
+	data := make([]byte, size)
+	binary.LittleEndian.PutUint32(data, magic)
+	for i := 0; i < words; i++ {
+		offset := 4 + i*8
+		shift := uint(32 * i)
+		binary.LittleEndian.PutUint32(data[offset:], uint32(caps.Permitted>>shift))
+		binary.LittleEndian.PutUint32(data[offset+4:], uint32(caps.Inheritable>>shift))
+	}
+	if version == vfsCapRevision3 && caps.RootID != UnsetRootID {
+		binary.LittleEndian.PutUint32(data[vfsCapSizeRevision2:], uint32(caps.RootID))
+	}
+
+	return data
+}
+
+// WriteFileCaps writes caps to the security.capability extended attribute of
+// the file at path, following symbolic links, without going through
+// libcap-ng.
+func WriteFileCaps(path string, caps FileCaps) error {
+	err := syscall.Setxattr(path, fileCapsXattr, MarshalFileCaps(caps), 0)
+	if err == syscall.EPERM {
+		return requirementError(OpSetFileCaps, err)
+	}
+	return err
+}
+
+// RemoveFileCaps removes the capabilities of the file at path. Removing the
+// capabilities of a file without any is not an error.
+func RemoveFileCaps(path string) error {
+	err := syscall.Removexattr(path, fileCapsXattr)
+	switch err {
+	case nil, syscall.ENODATA:
+		return nil
+	case syscall.EPERM:
+		return requirementError(OpSetFileCaps, err)
+	}
+	return err
+}
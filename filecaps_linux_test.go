@@ -0,0 +1,220 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParseFileCaps(t *testing.T) {
+	toCheck := []struct {
+		name     string
+		data     []byte
+		expected FileCaps
+	}{
+		{
+			name: "revision 2 cap_net_raw+ep",
+			data: []byte{
+				0x01, 0x00, 0x00, 0x02,
+				0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			expected: FileCaps{
+				Version:   2,
+				Permitted: NewCapSet(CAPNetRaw),
+				Effective: true,
+				RootID:    UnsetRootID,
+			},
+		},
+		{
+			name: "revision 3 cap_mac_admin+i",
+			data: []byte{
+				0x00, 0x00, 0x00, 0x03,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+				0xa0, 0x86, 0x01, 0x00,
+			},
+			expected: FileCaps{
+				Version:     3,
+				Inheritable: NewCapSet(CAPMACAdmin),
+				RootID:      100000,
+			},
+		},
+		{
+			name: "revision 1 cap_chown+p",
+			data: []byte{
+				0x00, 0x00, 0x00, 0x01,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			expected: FileCaps{
+				Version:   1,
+				Permitted: NewCapSet(CAPCHOWN),
+				RootID:    UnsetRootID,
+			},
+		},
+	}
+
+	for _, check := range toCheck {
+		caps, err := ParseFileCaps(check.data)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if caps != check.expected {
+			t.Errorf("'%s' expected %+v got %+v", check.name, check.expected, caps)
+		}
+		if data := MarshalFileCaps(check.expected); !bytes.Equal(data, check.data) {
+			t.Errorf("'%s' expected %x to marshal to %x", check.name, data, check.data)
+		}
+	}
+
+	_, err := ParseFileCaps([]byte{0x00, 0x00, 0x00, 0x02, 0x00})
+	if !errors.Is(err, ErrInvalidFileCaps) {
+		t.Errorf("Expected %s, got %v", ErrInvalidFileCaps, err)
+	}
+}
+
+func TestFileCapsText(t *testing.T) {
+	toCheck := []struct {
+		text     string
+		expected FileCaps
+		output   string
+	}{
+		{
+			text:     "cap_net_raw+ep",
+			expected: FileCaps{Permitted: NewCapSet(CAPNetRaw), Effective: true},
+			output:   "cap_net_raw=ep",
+		},
+		{
+			text: "CAP_NET_RAW,cap_net_admin=eip",
+			expected: FileCaps{
+				Permitted:   NewCapSet(CAPNetRaw, CAPNetAdmin),
+				Inheritable: NewCapSet(CAPNetRaw, CAPNetAdmin),
+				Effective:   true,
+			},
+			output: "cap_net_admin,cap_net_raw=eip",
+		},
+		{
+			text: "cap_kill+i cap_chown=p",
+			expected: FileCaps{
+				Permitted:   NewCapSet(CAPCHOWN),
+				Inheritable: NewCapSet(CAPKill),
+			},
+			output: "cap_kill=i cap_chown+p",
+		},
+		{
+			text:     "=p cap_sys_admin-p",
+			expected: FileCaps{Permitted: FullCapSet().Drop(CAPSysAdmin)},
+			output:   "=p cap_sys_admin-p",
+		},
+		{
+			text:     "all=ep",
+			expected: FileCaps{Permitted: FullCapSet(), Effective: true},
+			output:   "=ep",
+		},
+		{
+			text:     "cap_kill=ep cap_kill=",
+			expected: FileCaps{},
+			output:   "=",
+		},
+	}
+
+	for _, check := range toCheck {
+		check.expected.RootID = UnsetRootID
+		caps, err := ParseFileCapsText(check.text)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.text, err)
+			continue
+		}
+		if caps != check.expected {
+			t.Errorf("'%s' expected %s but have %s instead", check.text, check.expected, caps)
+		}
+		if check.output != "" && caps.String() != check.output {
+			t.Errorf("'%s' expected '%s' but have '%s' instead", check.text, check.output, caps)
+		}
+		if again, err := ParseFileCapsText(caps.String()); err != nil || again != caps {
+			t.Errorf("'%s' expected '%s' to parse back, have %s (%v) instead", check.text, caps, again, err)
+		}
+	}
+
+	for _, invalid := range []string{
+		"cap_net_raw",
+		"+ep",
+		"cap_net_raw+x",
+		"cap_net_raw+",
+		"cap_unknown+p",
+		"cap_net_raw+ep cap_kill+p",
+	} {
+		if _, err := ParseFileCapsText(invalid); err == nil {
+			t.Errorf("'%s' expected an error", invalid)
+		}
+	}
+}
+
+func TestWriteFileCaps(t *testing.T) {
+	state, err := ReadProcessState(0)
+	if err != nil || !state.Caps.Effective.Has(CAPSetFCap) {
+		t.Skip("Missing capabilities: setfcap")
+	}
+
+	path := filepath.Join(t.TempDir(), "ping")
+	if err := os.WriteFile(path, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FileCaps{Version: 2, Permitted: NewCapSet(CAPNetRaw), Effective: true, RootID: UnsetRootID}
+	err = WriteFileCaps(path, expected)
+	if errors.Is(err, syscall.ENOTSUP) {
+		t.Skip("The file system does not support file capabilities")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	caps, err := ReadFileCaps(path)
+	if err != nil || caps != expected {
+		t.Errorf("Expected %+v but have %+v (%v) instead", expected, caps, err)
+	}
+
+	if err := RemoveFileCaps(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := RemoveFileCaps(path); err != nil {
+		t.Errorf("Expected removing twice to succeed, have %s", err)
+	}
+	if caps, err := ReadFileCaps(path); err != nil || caps.Version != 0 {
+		t.Errorf("Expected no capabilities, have %+v (%v)", caps, err)
+	}
+}
+
+func TestCapabilitiesText(t *testing.T) {
+	toCheck := []struct {
+		caps     Capabilities
+		expected string
+	}{
+		{caps: Capabilities{}, expected: "="},
+		{
+			caps:     Capabilities{Effective: FullCapSet().Drop(CAPSysResource), Permitted: FullCapSet().Drop(CAPSysResource)},
+			expected: "=ep cap_sys_resource-ep",
+		},
+		{
+			caps: Capabilities{
+				Effective:   NewCapSet(CAPNetRaw),
+				Permitted:   NewCapSet(CAPNetRaw, CAPNetAdmin),
+				Inheritable: NewCapSet(CAPNetAdmin),
+			},
+			expected: "cap_net_admin=ip cap_net_raw+ep",
+		},
+	}
+
+	for _, check := range toCheck {
+		if text := check.caps.Text(); text != check.expected {
+			t.Errorf("Expected '%s' but have '%s' instead", check.expected, text)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String returns the capabilities in the text format of libcap, as printed
+// by getcap, for example "cap_net_raw=ep". A file without capabilities is
+// written as "=".
+func (c FileCaps) String() string {
+	var effective CapSet
+	if c.Effective {
+		effective = c.Permitted.Union(c.Inheritable)
+	}
+	return libcapText(effective, c.Inheritable, c.Permitted)
+}
+
+// Text returns the effective, inheritable and permitted sets in the text
+// format of libcap, as printed by the "Current:" line of capsh --print, for
+// example "=ep cap_sys_resource-ep".
+func (c Capabilities) Text() string {
+	return libcapText(c.Effective, c.Inheritable, c.Permitted)
+}
+
+// flags of libcapText, in the bit order of libcap
+const (
+	textEffective = 1 << iota
+	textPermitted
+	textInheritable
+)
+
+// libcapText follows cap_to_text of libcap: the flags shared by most
+// capabilities are written first for every capability, then every group of
+// capabilities holding other flags is written with the flags it adds and
+// removes. Without shared flags, the first group is written with "=" and the
+// others with "+".
+func libcapText(effective, inheritable, permitted CapSet) string {
+	flagsOf := func(capability Capability) int {
+		flags := 0
+		if effective.Has(capability) {
+			flags |= textEffective
+		}
+		if permitted.Has(capability) {
+			flags |= textPermitted
+		}
+		if inheritable.Has(capability) {
+			flags |= textInheritable
+		}
+		return flags
+	}
+	flagsText := func(flags int) string {
+		text := ""
+		if flags&textEffective != 0 {
+			text += "e"
+		}
+		if flags&textInheritable != 0 {
+			text += "i"
+		}
+		if flags&textPermitted != 0 {
+			text += "p"
+		}
+		return text
+	}
+
+	caps := FullCapSet().Union(effective).Union(inheritable).Union(permitted).List()
+	var histogram [8]int
+	for _, capability := range caps {
+		histogram[flagsOf(capability)]++
+	}
+	base := 7
+	for flags := 6; flags >= 0; flags-- {
+		if histogram[flags] >= histogram[base] {
+			base = flags
+		}
+	}
+
+	var clauses []string
+	if base != 0 {
+		clauses = append(clauses, "="+flagsText(base))
+	}
+	for flags := 7; flags >= 0; flags-- {
+		if flags == base || histogram[flags] == 0 {
+			continue
+		}
+
+		var names []string
+		for _, capability := range caps {
+			if flagsOf(capability) != flags {
+				continue
+			}
+			name := capability.String()
+			if !strings.HasPrefix(name, "cap_") {
+				name = "cap_" + name
+			}
+			names = append(names, name)
+		}
+
+		clause := strings.Join(names, ",")
+		if base == 0 && len(clauses) == 0 {
+			clause += "=" + flagsText(flags)
+		} else {
+			if added := flags &^ base; added != 0 {
+				clause += "+" + flagsText(added)
+			}
+			if removed := base &^ flags; removed != 0 {
+				clause += "-" + flagsText(removed)
+			}
+		}
+		clauses = append(clauses, clause)
+	}
+
+	if len(clauses) == 0 {
+		return "="
+	}
+	return strings.Join(clauses, " ")
+}
+
+// ParseFileCapsText parses file capabilities in the text format of libcap,
+// as accepted by setcap, for example "cap_net_raw+ep" or
+// "cap_net_admin,cap_net_raw=eip cap_kill+i".
+//
+// Every whitespace separated clause is a comma separated list of
+// capabilities, empty or "all" for every capability, followed by one or more
+// operators with flags: "=" sets the flags of the listed capabilities to
+// exactly the given ones, "+" raises and "-" lowers them. Flags are "e" for
+// effective, "i" for inheritable and "p" for permitted.
+//
+// As a file holds a single effective bit, the effective flag must be set
+// either on none, or on every permitted and inheritable capability.
+//
+// The Version of the result is 0, leaving the revision to MarshalFileCaps.
+func ParseFileCapsText(text string) (FileCaps, error) {
+	var effective, inheritable, permitted CapSet
+
+	for _, clause := range strings.Fields(text) {
+		idx := strings.IndexAny(clause, "=+-")
+		if idx < 0 {
+			return FileCaps{}, fmt.Errorf("%w: missing operator in %q", ErrInvalidFileCapsText, clause)
+		}
+
+		list, actions := clause[:idx], clause[idx:]
+		var set CapSet
+		if list == "" || list == "all" {
+			if list == "" && actions[0] != '=' {
+				return FileCaps{}, fmt.Errorf("%w: missing capabilities in %q", ErrInvalidFileCapsText, clause)
+			}
+			set = FullCapSet()
+		} else {
+			for _, name := range strings.Split(list, ",") {
+				if name == "all" {
+					set = set.Union(FullCapSet())
+					continue
+				}
+				capability, err := ParseCapability(name)
+				if err != nil {
+					return FileCaps{}, fmt.Errorf("%w: %s", err, name)
+				}
+				set = set.Add(capability)
+			}
+		}
+
+		for len(actions) > 0 {
+			op := actions[0]
+			end := strings.IndexAny(actions[1:], "=+-") + 1
+			if end == 0 {
+				end = len(actions)
+			}
+			flags := actions[1:end]
+			actions = actions[end:]
+
+			if flags == "" && op != '=' {
+				return FileCaps{}, fmt.Errorf("%w: missing flags after %q in %q", ErrInvalidFileCapsText, op, clause)
+			}
+			if op == '=' {
+				effective = effective.Difference(set)
+				inheritable = inheritable.Difference(set)
+				permitted = permitted.Difference(set)
+			}
+
+			for _, flag := range flags {
+				var target *CapSet
+				switch flag {
+				case 'e':
+					target = &effective
+				case 'i':
+					target = &inheritable
+				case 'p':
+					target = &permitted
+				default:
+					return FileCaps{}, fmt.Errorf("%w: unknown flag %q in %q", ErrInvalidFileCapsText, flag, clause)
+				}
+				if op == '-' {
+					*target = target.Difference(set)
+				} else {
+					*target = target.Union(set)
+				}
+			}
+		}
+	}
+
+	if !effective.IsEmpty() && effective != permitted.Union(inheritable) {
+		return FileCaps{}, fmt.Errorf(
+			"%w: the effective flag must be set on every permitted and inheritable capability, or on none",
+			ErrInvalidFileCapsText,
+		)
+	}
+
+	return FileCaps{
+		Permitted:   permitted,
+		Inheritable: inheritable,
+		Effective:   !effective.IsEmpty(),
+		RootID:      UnsetRootID,
+	}, nil
+}
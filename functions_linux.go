@@ -12,7 +12,10 @@ package gocapng
 //}
 import "C"
 import (
+	"fmt"
+	"io"
 	"os"
+	"runtime"
 	"unsafe"
 )
 
@@ -20,6 +23,8 @@ import (
 // When done using you must use the Close function.
 type CapNG struct{}
 
+var _ Manager = CapNG{}
+
 // Init initialize the pointer for all supported functions
 func Init() *CapNG {
 	return &CapNG{}
@@ -43,8 +48,28 @@ func (cp CapNG) Clear(set Select) {
 // the bounding set, SelectBoth if filling both is desired, SelectAmbient if
 // only operating on the ambient capabilities, or SelectAll if clearing all is
 // desired.
+//
+// Capabilities the running kernel does not support are never set, even if
+// libcap-ng knows about them, and neither are the ones the ratchet refuses
+// (see EngageRatchet).
 func (cp CapNG) Fill(set Select) {
 	C.capng_fill(C.capng_select_t(set))
+
+	for capability := kernelLastCap() + 1; capability <= CAPLastCap; capability++ {
+		cp.Update(ActDrop, selectTypes(set), capability)
+	}
+
+	if limit, engaged := ratchetLimit(); engaged {
+		for _, t := range allTypes {
+			if selectTypes(set)&t == 0 {
+				continue
+			}
+			for _, capability := range FullCapSet().Difference(limit.Get(t)).List() {
+				cp.Update(ActDrop, t, capability)
+			}
+		}
+		logf("Fill(%s): limited to the capabilities allowed by the ratchet", set)
+	}
 }
 
 // SetPID  set working pid.
@@ -76,8 +101,13 @@ func (cp CapNG) GetCapsProcess() bool {
 // on multiple sets. The last parameter, capability, is the capability define as
 // given in linux/capability.h (translated into Golang by this package).
 //
-// This returns true on success and false on failure.
+// This returns true on success and false on failure, including when the
+// ratchet refuses to add the capability (see EngageRatchet).
 func (cp CapNG) Update(action Act, t Type, capability Capability) bool {
+	if action == ActAdd && ratchetRefusesAdd(t, capability) {
+		return false
+	}
+
 	result := C.capng_update(
 		C.capng_act_t(action),
 		C.capng_type_t(t),
@@ -101,11 +131,15 @@ func (cp CapNG) Update(action Act, t Type, capability Capability) bool {
 // This function differs from update in that you may pass a list of
 // capabilities.
 //
-// This returns true on success and false on failure.
+// This returns true on success and false on failure, including when the
+// ratchet refuses to add one of the capabilities (see EngageRatchet).
 func (cp CapNG) Updatev(action Act, t Type, capability ...Capability) bool {
 	if len(capability) == 0 {
 		return false
 	}
+	if action == ActAdd && ratchetRefusesAdd(t, capability...) {
+		return false
+	}
 	var caps []C.int
 
 	for _, cap := range capability {
@@ -130,8 +164,15 @@ func (cp CapNG) Updatev(action Act, t Type, capability ...Capability) bool {
 // SelectAmbient if only operating on the ambient capabilities, or SelectAll if
 // applying all is desired.
 //
-//
+// Errors caused by a missing capability are returned as a *RequirementError
+// wrapping the error, use errors.Is to compare them. When the ratchet is
+// engaged, growing any set returns ErrRatchetEngaged (see EngageRatchet).
 func (cp CapNG) Apply(set Select) error {
+	pending := cp.pending()
+	if err := ratchetAllows(fmt.Sprintf("Apply(%s)", set), selectTypes(set), pending); err != nil {
+		return err
+	}
+
 	result := C.capng_apply(C.capng_select_t(set))
 
 	switch result {
@@ -142,7 +183,8 @@ func (cp CapNG) Apply(set Select) error {
 	case -3:
 		return ErrSelectBoundsAndFailureToReReadBoundingSet
 	case -4:
-		return ErrSelectBoundsCAPSetPCap
+		// libcap-ng checks CAP_SETPCAP itself, without a system call
+		return requirementError(OpDropBoundingSet, ErrSelectBoundsCAPSetPCap)
 	case -5:
 		return ErrSelectCapsCapsetSyscall
 	case -6:
@@ -153,6 +195,7 @@ func (cp CapNG) Apply(set Select) error {
 		return ErrSelectAmbientProcessCapabilitiesSetting
 	}
 
+	tightenRatchet(selectTypes(set), pending)
 	return nil
 }
 
@@ -205,8 +248,21 @@ func (cp CapNG) Lock() bool {
 //    FlagClearAmbient
 //        Clear ambient capabilities regardless of the internal representation
 //        already setup prior to changing the uid/gid.
+//
+// Errors caused by a missing capability (EPERM) are returned as a
+// *RequirementError wrapping the error, use errors.Is to compare them. Other
+// failures, such as reaching RLIMIT_NPROC, return the error itself. When the
+// ratchet is engaged, growing any set returns ErrRatchetEngaged (see
+// EngageRatchet).
 func (cp CapNG) ChangeID(uid, gid int, flag Flags) error {
-	result := C.capng_change_id(C.int(uid), C.int(gid), C.capng_flags_t(flag))
+	applied := TypeEffective | TypePermitted | TypeInheritable | TypeAmbient
+	pending := cp.pending()
+	operation := fmt.Sprintf("ChangeID(%d, %d, %s)", uid, gid, flagsString(flag))
+	if err := ratchetAllows(operation, applied, pending); err != nil {
+		return err
+	}
+
+	result, errno := C.capng_change_id(C.int(uid), C.int(gid), C.capng_flags_t(flag))
 	switch result {
 	case -1:
 		return ErrCAPNGNotInittedProperly
@@ -215,20 +271,22 @@ func (cp CapNG) ChangeID(uid, gid int, flag Flags) error {
 	case -3:
 		return ErrApplyingIntermediateCapabilitiesFailed
 	case -4:
-		return ErrChangingGIDFailed
+		return permissionError(OpChangeGID, ErrChangingGIDFailed, errno)
 	case -5:
-		return ErrDroppingSupplementalGroupsFailed
+		return permissionError(OpSetGroups, ErrDroppingSupplementalGroupsFailed, errno)
 	case -6:
-		return ErrChangingUIDFailed
+		return permissionError(OpChangeUID, ErrChangingUIDFailed, errno)
 	case -7:
 		return ErrDroppingAbilityRetainUIDChangeFailed
 	case -8:
-		return ErrClearingBoundingSet
+		return permissionError(OpDropBoundingSet, ErrClearingBoundingSet, errno)
 	case -9:
 		return ErrDroppingCAPSETPCAP
 	case -10:
 		return ErrInitializedSupplementalGroups
 	}
+
+	tightenRatchet(applied, pending)
 	return nil
 }
 
@@ -354,6 +412,9 @@ func (cp CapNG) HaveCapability(which Type, capability Capability) bool {
 // If PrintBuffer was selected for where, this will be the text buffer and NULL
 // on failure. If PrintStdOut was selected then this value will be NULL no matter
 // what.
+//
+// WriteCaps writes the same layout to an io.Writer instead of the standard
+// output of C.
 func (cp CapNG) PrintCapsNumberic(where Print, set Select) string {
 	result := C.capng_print_caps_numeric(
 		C.capng_print_t(where),
@@ -390,6 +451,9 @@ func (cp CapNG) PrintCapsNumberic(where Print, set Select) string {
 // If PrintBuffer was selected for where, this will be the string buffer and
 // empty string on failure. If PrintStdOut was selected then this value will be
 // empty string no matter what.
+//
+// WriteCaps writes the same names to an io.Writer instead of the standard
+// output of C.
 func (cp CapNG) PrintCapsText(where Print, which Type) string {
 	result := C.capng_print_caps_text(
 		C.capng_print_t(where),
@@ -413,6 +477,15 @@ func (cp CapNG) PrintCapsText(where Print, which Type) string {
 	return str
 }
 
+// WriteCaps writes the capabilities stored in libcap-ng for the sets of set
+// to w in the given layout.
+//
+// Unlike PrintCapsNumberic and PrintCapsText, the output goes through w
+// instead of the standard output of C, and every set can be written at once.
+func (cp CapNG) WriteCaps(w io.Writer, set Select, format Format) error {
+	return WriteCapabilities(w, cp.pending(), set, format)
+}
+
 // NameToCapability  convert capability text to integer
 //
 // NameToCapability will take the string being passed and look it up to see what
@@ -451,3 +524,59 @@ func (cp CapNG) CapabilityToName(capability Capability) string {
 	name := C.GoString(result)
 	return name
 }
+
+// pending reads the capabilities stored inside libcap-ng's state table
+func (cp CapNG) pending() Capabilities {
+	var caps Capabilities
+	for capability := Capability(0); capability <= CAPLastCap; capability++ {
+		for _, t := range []Type{
+			TypeEffective, TypePermitted, TypeInheritable, TypeBoundingSet, TypeAmbient,
+		} {
+			if cp.HaveCapability(t, capability) {
+				caps.Set(t, caps.Get(t).Add(capability))
+			}
+		}
+	}
+	return caps
+}
+
+// DryRunApply reports what Apply would do without doing it.
+//
+// DryRunApply compares the capabilities stored in libcap-ng with the ones of
+// the running process, and returns the before and after state of every set,
+// together with the errors Apply is expected to return given the current
+// privileges.
+//
+// As libcap-ng changes the calling thread, the state of that thread is read.
+// Callers that rely on the report for a later Apply lock the goroutine to its
+// thread with runtime.LockOSThread around both calls.
+func (cp CapNG) DryRunApply(set Select) (DryRun, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	before, err := readThreadState()
+	if err != nil {
+		return DryRun{}, err
+	}
+	return PredictApply(before, cp.pending(), set), nil
+}
+
+// DryRunChangeID reports what ChangeID would do without doing it.
+//
+// DryRunChangeID follows the steps ChangeID takes (keeping the capabilities
+// across the uid change, adding CAPSetUID and CAPSetGID when required,
+// changing gid, supplement groups and uid, and finally applying the stored
+// capabilities), and returns the before and after state of the process,
+// together with the error ChangeID is expected to stop on.
+//
+// Like DryRunApply, the state of the calling thread is read.
+func (cp CapNG) DryRunChangeID(uid, gid int, flag Flags) (DryRun, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	before, err := readThreadState()
+	if err != nil {
+		return DryRun{}, err
+	}
+	return PredictChangeID(before, cp.pending(), uid, gid, flag), nil
+}
@@ -222,3 +222,23 @@ Ambient Set: 00000000, 00000000
 		}
 	})
 }
+
+func TestCapabilityNamesMatchLibCapNG(t *testing.T) {
+	caps := Init()
+	if caps == nil {
+		t.Error("caps is nil")
+	}
+
+	for capability := Capability(0); capability <= CAPLastCap; capability++ {
+		name := caps.CapabilityToName(capability)
+		if name == "" {
+			// libcap-ng or the running kernel are older than capability.go
+			t.Logf("libcap-ng does not know %s", capability)
+			continue
+		}
+
+		if name != capability.String() {
+			t.Errorf("%d: capability.go has '%s', libcap-ng has '%s'", capability, capability, name)
+		}
+	}
+}
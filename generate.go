@@ -0,0 +1,3 @@
+package gocapng
+
+//go:generate go run ./internal/capgen -in internal/capgen/linux/capability.h -out capability.go
@@ -1,3 +1,3 @@
 module github.com/ik5/gocapng
 
-go 1.17
+go 1.21
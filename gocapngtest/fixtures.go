@@ -0,0 +1,86 @@
+//go:build linux
+
+package gocapngtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+// ProcStatus returns the content of /proc/<pid>/status for state, holding
+// the fields gocapng parses.
+func ProcStatus(name string, state gocapng.ProcessState) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Name:\t%s\n", name)
+	fmt.Fprintf(&b, "Pid:\t%d\n", state.PID)
+	fmt.Fprintf(&b, "PPid:\t%d\n", state.PPID)
+	fmt.Fprintf(&b, "Uid:\t%d\t%d\t%d\t%d\n", state.UID.Real, state.UID.Effective, state.UID.Saved, state.UID.FS)
+	fmt.Fprintf(&b, "Gid:\t%d\t%d\t%d\t%d\n", state.GID.Real, state.GID.Effective, state.GID.Saved, state.GID.FS)
+	fmt.Fprintf(&b, "CapInh:\t%016x\n", uint64(state.Caps.Inheritable))
+	fmt.Fprintf(&b, "CapPrm:\t%016x\n", uint64(state.Caps.Permitted))
+	fmt.Fprintf(&b, "CapEff:\t%016x\n", uint64(state.Caps.Effective))
+	fmt.Fprintf(&b, "CapBnd:\t%016x\n", uint64(state.Caps.Bounding))
+	fmt.Fprintf(&b, "CapAmb:\t%016x\n", uint64(state.Caps.Ambient))
+
+	noNewPrivs := 0
+	if state.NoNewPrivs {
+		noNewPrivs = 1
+	}
+	fmt.Fprintf(&b, "NoNewPrivs:\t%d\n", noNewPrivs)
+
+	return b.String()
+}
+
+// ProcTree is a fake /proc directory in a temporary directory of a test
+type ProcTree struct {
+	t testing.TB
+	// Root is the directory to use in place of /proc
+	Root string
+}
+
+// NewProcTree creates an empty ProcTree, removed when the test ends
+func NewProcTree(t testing.TB) *ProcTree {
+	return &ProcTree{t: t, Root: t.TempDir()}
+}
+
+// Add writes <Root>/<pid>/status and <Root>/<pid>/comm for state, and returns
+// the directory of the process.
+func (p *ProcTree) Add(name string, state gocapng.ProcessState) string {
+	p.t.Helper()
+
+	p.WriteFile(state.PID, "status", ProcStatus(name, state))
+	p.WriteFile(state.PID, "comm", name+"\n")
+	return p.Dir(state.PID)
+}
+
+// Dir returns the directory of pid
+func (p *ProcTree) Dir(pid int) string {
+	return filepath.Join(p.Root, strconv.Itoa(pid))
+}
+
+// WriteFile writes the file name in the directory of pid, creating missing
+// directories, and fails the test on error.
+func (p *ProcTree) WriteFile(pid int, name, content string) {
+	p.t.Helper()
+
+	path := filepath.Join(p.Dir(pid), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// FileCapsXattr returns the raw security.capability extended attribute
+// holding caps, the reverse of gocapng.ParseFileCaps.
+func FileCapsXattr(caps gocapng.FileCaps) []byte {
+	return gocapng.MarshalFileCaps(caps)
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+// Package gocapngtest provides helpers for the tests of packages using
+// gocapng: skipping tests depending on the privileges of the test process,
+// building /proc and security.capability fixtures, and comparing capability
+// states with golden files.
+//
+//	func TestBind(t *testing.T) {
+//		gocapngtest.SkipUnlessHave(t, gocapng.CAPNetBindService)
+//		...
+//	}
+package gocapngtest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+// SkipUnlessHave skips the test unless every capability of caps is in the
+// effective set of the test process.
+//
+// Capabilities the running kernel does not know are never held.
+func SkipUnlessHave(t testing.TB, caps ...gocapng.Capability) {
+	t.Helper()
+
+	state, err := gocapng.ReadProcessState(0)
+	if err != nil {
+		t.Skipf("Unable to read the capabilities of the test process: %s", err)
+	}
+
+	var missing []string
+	for _, capability := range caps {
+		if !state.Caps.Effective.Has(capability) {
+			missing = append(missing, capability.String())
+		}
+	}
+	if len(missing) > 0 {
+		t.Skipf("Missing capabilities: %s", strings.Join(missing, ","))
+	}
+}
+
+// SkipIfRoot skips the test when the test process runs with an effective uid
+// of 0, for tests of the unprivileged code paths.
+func SkipIfRoot(t testing.TB) {
+	t.Helper()
+
+	if os.Geteuid() == 0 {
+		t.Skip("Running as root")
+	}
+}
@@ -0,0 +1,117 @@
+//go:build linux
+
+package gocapngtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+func TestSkipUnlessHave(t *testing.T) {
+	state, err := gocapng.ReadProcessState(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("held", func(t *testing.T) {
+		SkipUnlessHave(t, state.Caps.Effective.List()...)
+	})
+
+	skipped := false
+	t.Run("missing", func(t *testing.T) {
+		defer func() { skipped = t.Skipped() }()
+		// the last capability is unknown to every kernel
+		SkipUnlessHave(t, gocapng.Capability(63))
+	})
+	if !skipped {
+		t.Error("Expected the test to be skipped")
+	}
+}
+
+func TestProcTree(t *testing.T) {
+	expected := gocapng.ProcessState{
+		PID:  42,
+		PPID: 1,
+		Caps: gocapng.Capabilities{
+			Effective: gocapng.NewCapSet(gocapng.CAPNetRaw),
+			Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPKill),
+			Bounding:  gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPKill, gocapng.CAPCHOWN),
+		},
+		UID:        gocapng.IDs{Real: 1000, Effective: 1001, Saved: 1002, FS: 1003},
+		GID:        gocapng.IDs{Real: 100, Effective: 100, Saved: 100, FS: 100},
+		NoNewPrivs: true,
+	}
+
+	tree := NewProcTree(t)
+	dir := tree.Add("ping", expected)
+
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil || string(comm) != "ping\n" {
+		t.Errorf("Expected comm 'ping', have %q (%v) instead", comm, err)
+	}
+
+	got, err := gocapng.ReadProcessStateAt(tree.Root, expected.PID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got != expected {
+		t.Errorf("Expected %+v but have %+v instead", expected, got)
+	}
+}
+
+func TestFileCapsXattr(t *testing.T) {
+	type toCheck struct {
+		name string
+		caps gocapng.FileCaps
+		size int
+	}
+
+	checks := []toCheck{
+		{
+			name: "revision 2",
+			caps: gocapng.FileCaps{
+				Version:   2,
+				Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPBPF),
+				Effective: true,
+				RootID:    gocapng.UnsetRootID,
+			},
+			size: 20,
+		},
+		{
+			name: "revision 3",
+			caps: gocapng.FileCaps{
+				Version:     3,
+				Inheritable: gocapng.NewCapSet(gocapng.CAPKill),
+				RootID:      1000,
+			},
+			size: 24,
+		},
+	}
+
+	for _, check := range checks {
+		data := FileCapsXattr(check.caps)
+		if len(data) != check.size {
+			t.Errorf("'%s' expected %d bytes but have %d instead", check.name, check.size, len(data))
+		}
+
+		got, err := gocapng.ParseFileCaps(data)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if got != check.caps {
+			t.Errorf("'%s' expected %+v but have %+v instead", check.name, check.caps, got)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "net_raw", gocapng.Capabilities{
+		Effective: gocapng.NewCapSet(gocapng.CAPNetRaw),
+		Permitted: gocapng.NewCapSet(gocapng.CAPNetRaw),
+		Bounding:  gocapng.NewCapSet(gocapng.CAPNetRaw, gocapng.CAPNetBindService),
+	})
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package gocapngtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+var update = flag.Bool("gocapng.update", false, "rewrite the golden files of gocapngtest.Golden")
+
+// Format returns the text Golden compares: one line per set, in the order
+// of /proc/<pid>/status.
+func Format(caps gocapng.Capabilities) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "inheritable: %s\n", caps.Inheritable)
+	fmt.Fprintf(&b, "permitted: %s\n", caps.Permitted)
+	fmt.Fprintf(&b, "effective: %s\n", caps.Effective)
+	fmt.Fprintf(&b, "bounding: %s\n", caps.Bounding)
+	fmt.Fprintf(&b, "ambient: %s\n", caps.Ambient)
+
+	return b.String()
+}
+
+// Golden compares caps with testdata/<name>.golden, and reports every set
+// that differs. Running the tests with -gocapng.update writes caps to the
+// file instead.
+func Golden(t testing.TB, name string, caps gocapng.Capabilities) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	actual := Format(caps)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read the golden file, run the test with -gocapng.update to create it: %s", err)
+	}
+
+	expectedLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	actualLines := strings.Split(strings.TrimRight(actual, "\n"), "\n")
+	for i, line := range actualLines {
+		if i >= len(expectedLines) {
+			t.Errorf("%s: unexpected %q", path, line)
+			continue
+		}
+		if line != expectedLines[i] {
+			t.Errorf("%s: expected %q but have %q instead", path, expectedLines[i], line)
+		}
+	}
+	for i := len(actualLines); i < len(expectedLines); i++ {
+		t.Errorf("%s: missing %q", path, expectedLines[i])
+	}
+}
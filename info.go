@@ -0,0 +1,142 @@
+package gocapng
+
+// CapabilityInfo describes a capability for humans
+type CapabilityInfo struct {
+	Capability Capability
+	// Name is the libcap-ng style name, for example "net_raw"
+	Name string
+	// Description is the documentation of linux/capability.h
+	Description string
+	// Since is the kernel version that introduced the capability
+	Since string
+	Risk  RiskTier
+	// RootEquivalent is true when the capability alone is known to be
+	// trivially escalatable to full root
+	RootEquivalent bool
+	// Escalation explains how a RootEquivalent capability leads to root
+	Escalation string
+}
+
+type capabilityMetadata struct {
+	since      string
+	risk       RiskTier
+	escalation string
+}
+
+var capabilityCatalog = [...]capabilityMetadata{
+	CAPCHOWN: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "chown /etc/shadow or /etc/sudoers to the process user and rewrite it",
+	},
+	CAPDACOverride: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "write /etc/shadow, /etc/sudoers or any root owned executable",
+	},
+	CAPDACReadSearch: {since: "2.2", risk: RiskHigh},
+	CAPFOwner: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "chmod /etc/shadow or /etc/sudoers writable and rewrite it",
+	},
+	CAPFSetID: {since: "2.2", risk: RiskMedium},
+	CAPKill:   {since: "2.2", risk: RiskMedium},
+	CAPSetGID: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "setgid to the disk group and write the root file system block device",
+	},
+	CAPSetUID: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "setuid(0)",
+	},
+	CAPSetPCap:        {since: "2.2", risk: RiskHigh},
+	CAPLinuxImmutable: {since: "2.2", risk: RiskLow},
+	CAPNetBindService: {since: "2.2", risk: RiskLow},
+	CAPNetBroadcast:   {since: "2.2", risk: RiskLow},
+	CAPNetAdmin:       {since: "2.2", risk: RiskHigh},
+	CAPNetRaw:         {since: "2.2", risk: RiskMedium},
+	CAPIPCLock:        {since: "2.2", risk: RiskLow},
+	CAPIPCOwner:       {since: "2.2", risk: RiskMedium},
+	CAPSysModule: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "load a kernel module",
+	},
+	CAPSysRawIO: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "write kernel memory through /dev/mem or raw I/O ports",
+	},
+	CAPSysChRoot: {since: "2.2", risk: RiskMedium},
+	CAPSysPTrace: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "ptrace a root process and inject code into it",
+	},
+	CAPSysPAcct: {since: "2.2", risk: RiskLow},
+	CAPSysAdmin: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "mount a file system holding a setuid root shell, or bind mount over /etc/shadow",
+	},
+	CAPSysBoot: {
+		since:      "2.2",
+		risk:       RiskCritical,
+		escalation: "kexec_load a kernel of choice",
+	},
+	CAPSysNice:      {since: "2.2", risk: RiskLow},
+	CAPSysResource:  {since: "2.2", risk: RiskMedium},
+	CAPSysTime:      {since: "2.2", risk: RiskMedium},
+	CAPSysTTYConfig: {since: "2.2", risk: RiskLow},
+	CAPMkNod: {
+		since:      "2.4",
+		risk:       RiskCritical,
+		escalation: "create a device node for the root file system block device and write it",
+	},
+	CAPLease:        {since: "2.4", risk: RiskLow},
+	CAPAuditWrite:   {since: "2.6.11", risk: RiskLow},
+	CAPAuditControl: {since: "2.6.11", risk: RiskMedium},
+	CAPSetFCap: {
+		since:      "2.6.24",
+		risk:       RiskCritical,
+		escalation: "set cap_setuid+ep on an executable the process owns and run it",
+	},
+	CAPMACOverride:       {since: "2.6.25", risk: RiskHigh},
+	CAPMACAdmin:          {since: "2.6.25", risk: RiskHigh},
+	CAPSYSLOG:            {since: "2.6.37", risk: RiskLow},
+	CAPWakeAlarm:         {since: "3.0", risk: RiskLow},
+	CAPBlockSuspend:      {since: "3.5", risk: RiskLow},
+	CAPAuditRead:         {since: "3.16", risk: RiskLow},
+	CAPPerfmon:           {since: "5.8", risk: RiskMedium},
+	CAPBPF:               {since: "5.8", risk: RiskHigh},
+	CAPCheckpointRestore: {since: "5.9", risk: RiskMedium},
+}
+
+// Info returns the description, history and risk classification of the
+// capability.
+//
+// Capabilities this package does not know about are returned with their
+// synthetic name and RiskUnknown.
+func (c Capability) Info() CapabilityInfo {
+	info := CapabilityInfo{
+		Capability: c,
+		Name:       c.String(),
+	}
+
+	if int(c) < len(capabilityDescriptions) {
+		info.Description = capabilityDescriptions[c]
+	}
+
+	if int(c) < len(capabilityCatalog) {
+		metadata := capabilityCatalog[c]
+		info.Since = metadata.since
+		info.Risk = metadata.risk
+		info.Escalation = metadata.escalation
+		info.RootEquivalent = metadata.escalation != ""
+	}
+
+	return info
+}
@@ -0,0 +1,69 @@
+package gocapng
+
+import "testing"
+
+func TestCapabilityInfo(t *testing.T) {
+	toCheck := []struct {
+		capability     Capability
+		name           string
+		since          string
+		risk           RiskTier
+		rootEquivalent bool
+	}{
+		{
+			capability:     CAPSysAdmin,
+			name:           "sys_admin",
+			since:          "2.2",
+			risk:           RiskCritical,
+			rootEquivalent: true,
+		},
+		{
+			capability: CAPNetBindService,
+			name:       "net_bind_service",
+			since:      "2.2",
+			risk:       RiskLow,
+		},
+		{
+			capability:     CAPSetFCap,
+			name:           "setfcap",
+			since:          "2.6.24",
+			risk:           RiskCritical,
+			rootEquivalent: true,
+		},
+		{
+			capability: CAPBPF,
+			name:       "bpf",
+			since:      "5.8",
+			risk:       RiskHigh,
+		},
+		{
+			capability: Capability(63),
+			name:       "cap_63",
+			risk:       RiskUnknown,
+		},
+	}
+
+	for _, check := range toCheck {
+		info := check.capability.Info()
+		if info.Name != check.name || info.Since != check.since ||
+			info.Risk != check.risk || info.RootEquivalent != check.rootEquivalent {
+			t.Errorf(
+				"'%s' expected to be %s/%s/%t but have %s/%s/%t instead",
+				check.name, check.since, check.risk, check.rootEquivalent,
+				info.Since, info.Risk, info.RootEquivalent,
+			)
+		}
+	}
+}
+
+func TestCapabilityInfoComplete(t *testing.T) {
+	for _, capability := range KnownCapabilities() {
+		info := capability.Info()
+		if info.Description == "" || info.Since == "" || info.Risk == RiskUnknown {
+			t.Errorf("'%s' expected to have complete metadata", info.Name)
+		}
+		if info.RootEquivalent && info.Risk != RiskCritical {
+			t.Errorf("'%s' expected to be critical as it is root equivalent", info.Name)
+		}
+	}
+}
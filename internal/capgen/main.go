@@ -0,0 +1,243 @@
+// capgen generates the Capability constants of gocapng from linux/capability.h
+//
+// Usage:
+//
+//	go run ./internal/capgen [-in capability.h] [-out capability.go]
+//
+// The constants, their documentation, the libcap-ng style names and the last
+// capability are all taken from the header, so adding a capability to the
+// package is a matter of updating the header and running go generate.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// capability is a single CAP_ define of capability.h
+type capability struct {
+	// Define is the name of the C define, for example CAP_NET_RAW
+	Define string
+	Value  int
+	// Doc holds the comment lines preceding the define
+	Doc []string
+}
+
+var defineRegexp = regexp.MustCompile(`^#define\s+(CAP_[A-Z0-9_]+)\s+([0-9]+)\s*$`)
+
+// goNames keeps the Go names that do not follow the generic conversion rules
+var goNames = map[string]string{
+	"CAP_CHOWN":      "CAPCHOWN",
+	"CAP_FOWNER":     "CAPFOwner",
+	"CAP_FSETID":     "CAPFSetID",
+	"CAP_SETGID":     "CAPSetGID",
+	"CAP_SETUID":     "CAPSetUID",
+	"CAP_SETPCAP":    "CAPSetPCap",
+	"CAP_SYS_RAWIO":  "CAPSysRawIO",
+	"CAP_SYS_CHROOT": "CAPSysChRoot",
+	"CAP_SYS_PTRACE": "CAPSysPTrace",
+	"CAP_SYS_PACCT":  "CAPSysPAcct",
+	"CAP_MKNOD":      "CAPMkNod",
+	"CAP_SETFCAP":    "CAPSetFCap",
+	"CAP_SYSLOG":     "CAPSYSLOG",
+}
+
+// initialisms are kept upper case in Go names
+var initialisms = map[string]bool{
+	"BPF": true,
+	"DAC": true,
+	"IPC": true,
+	"MAC": true,
+	"TTY": true,
+}
+
+func main() {
+	in := flag.String("in", "/usr/include/linux/capability.h", "capability.h to parse")
+	out := flag.String("out", "", "file to write, standard output when empty")
+	pkg := flag.String("pkg", "gocapng", "package name of the generated file")
+	flag.Parse()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open header: %s\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	caps, err := parse(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to parse %s: %s\n", *in, err)
+		os.Exit(1)
+	}
+
+	src, err := generate(*pkg, caps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to generate code: %s\n", err)
+		os.Exit(2)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", *out, err)
+		os.Exit(3)
+	}
+}
+
+// parse reads the CAP_ defines of capability.h together with the comments
+// preceding them
+func parse(r io.Reader) ([]capability, error) {
+	var (
+		caps       []capability
+		doc        []string
+		inComment  bool
+		afterBlank bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case inComment || strings.HasPrefix(line, "/*"):
+			// a comment after an empty line starts a new documentation block
+			if !inComment && afterBlank {
+				doc = nil
+			}
+			afterBlank = false
+			inComment = !strings.HasSuffix(line, "*/")
+			doc = append(doc, commentText(line))
+
+		case line == "":
+			afterBlank = true
+
+		default:
+			afterBlank = false
+			if match := defineRegexp.FindStringSubmatch(line); match != nil {
+				value, err := strconv.Atoi(match[2])
+				if err != nil {
+					return nil, err
+				}
+				if value != len(caps) {
+					return nil, fmt.Errorf("%s is %d, expected %d", match[1], value, len(caps))
+				}
+				caps = append(caps, capability{
+					Define: match[1],
+					Value:  value,
+					Doc:    trimEmpty(doc),
+				})
+			}
+			doc = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(caps) == 0 {
+		return nil, fmt.Errorf("no capabilities found")
+	}
+	return caps, nil
+}
+
+// commentText removes the comment markers of a line
+func commentText(line string) string {
+	line = strings.TrimPrefix(line, "/*")
+	line = strings.TrimSuffix(line, "*/")
+	line = strings.TrimRight(line, " \t*")
+	if strings.HasPrefix(line, "*") {
+		line = strings.TrimLeft(line, "*")
+		line = strings.TrimPrefix(line, " ")
+	} else {
+		line = strings.TrimLeft(line, " \t")
+	}
+	return strings.ReplaceAll(line, "\t", " ")
+}
+
+// trimEmpty removes empty leading and trailing lines
+func trimEmpty(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// goName returns the Go constant name of a CAP_ define
+func goName(define string) string {
+	if name, ok := goNames[define]; ok {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteString("CAP")
+	for _, word := range strings.Split(strings.TrimPrefix(define, "CAP_"), "_") {
+		if initialisms[word] {
+			b.WriteString(word)
+			continue
+		}
+		b.WriteString(word[:1])
+		b.WriteString(strings.ToLower(word[1:]))
+	}
+	return b.String()
+}
+
+// name returns the libcap-ng style name of a CAP_ define
+func name(define string) string {
+	return strings.ToLower(strings.TrimPrefix(define, "CAP_"))
+}
+
+// generate returns the formatted Go source for caps
+func generate(pkg string, caps []capability) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by internal/capgen from linux/capability.h. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	b.WriteString("// CAPS from /usr/include/linux/capability.h\nconst (\n")
+	for i, capability := range caps {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range capability.Doc {
+			fmt.Fprintf(&b, "\t// %s\n", line)
+		}
+		fmt.Fprintf(&b, "\t%s Capability = %d\n", goName(capability.Define), capability.Value)
+	}
+	b.WriteString(")\n\n")
+
+	last := caps[len(caps)-1]
+	b.WriteString("// CAPLastCap is the highest capability known to this package\n")
+	fmt.Fprintf(&b, "const CAPLastCap = %s\n\n", goName(last.Define))
+
+	b.WriteString("// capabilityNames holds the libcap-ng style name (CAP_ prefix removed and lower\n")
+	b.WriteString("// case) for every capability defined above.\n")
+	b.WriteString("var capabilityNames = [...]string{\n")
+	for _, capability := range caps {
+		fmt.Fprintf(&b, "\t%s: %q,\n", goName(capability.Define), name(capability.Define))
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// capabilityDescriptions holds the description of every capability defined\n")
+	b.WriteString("// above, as documented by linux/capability.h.\n")
+	b.WriteString("var capabilityDescriptions = [...]string{\n")
+	for _, capability := range caps {
+		fmt.Fprintf(&b, "\t%s: %q,\n", goName(capability.Define), strings.Join(capability.Doc, "\n"))
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedFileIsUpToDate(t *testing.T) {
+	f, err := os.Open("linux/capability.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	caps, err := parse(f)
+	if err != nil {
+		t.Fatalf("Unable to parse header: %s", err)
+	}
+
+	src, err := generate("gocapng", caps)
+	if err != nil {
+		t.Fatalf("Unable to generate code: %s", err)
+	}
+
+	current, err := os.ReadFile("../../capability.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, current) {
+		t.Error("capability.go is out of date, run go generate")
+	}
+}
+
+func TestParse(t *testing.T) {
+	header := `
+/**
+ ** POSIX-draft defined capabilities.
+ **/
+
+/* First line
+   second line. */
+
+#define CAP_CHOWN            0
+
+/* Allows one thing */
+/* Allows another thing */
+
+#define CAP_SYS_TTY_CONFIG   1
+
+/*
+ * Star prefixed
+ *   indented
+ */
+#define CAP_NEW_THING	2
+
+#define CAP_LAST_CAP         CAP_NEW_THING
+`
+	caps, err := parse(strings.NewReader(header))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	toCheck := []struct {
+		define string
+		goName string
+		name   string
+		doc    string
+	}{
+		{
+			define: "CAP_CHOWN",
+			goName: "CAPCHOWN",
+			name:   "chown",
+			doc:    "First line\nsecond line.",
+		},
+		{
+			define: "CAP_SYS_TTY_CONFIG",
+			goName: "CAPSysTTYConfig",
+			name:   "sys_tty_config",
+			doc:    "Allows one thing\nAllows another thing",
+		},
+		{
+			define: "CAP_NEW_THING",
+			goName: "CAPNewThing",
+			name:   "new_thing",
+			doc:    "Star prefixed\n  indented",
+		},
+	}
+
+	if len(caps) != len(toCheck) {
+		t.Fatalf("Expected %d capabilities, got %d", len(toCheck), len(caps))
+	}
+
+	for i, check := range toCheck {
+		if caps[i].Define != check.define {
+			t.Errorf("Expected %s, got %s", check.define, caps[i].Define)
+		}
+		if goName(caps[i].Define) != check.goName {
+			t.Errorf("'%s' expected Go name %s, got %s", check.define, check.goName, goName(caps[i].Define))
+		}
+		if name(caps[i].Define) != check.name {
+			t.Errorf("'%s' expected name %s, got %s", check.define, check.name, name(caps[i].Define))
+		}
+		if strings.Join(caps[i].Doc, "\n") != check.doc {
+			t.Errorf("'%s' expected doc %q, got %q", check.define, check.doc, strings.Join(caps[i].Doc, "\n"))
+		}
+	}
+}
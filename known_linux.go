@@ -0,0 +1,58 @@
+//go:build linux
+
+package gocapng
+
+import "sync"
+
+var lastCap struct {
+	once  sync.Once
+	value Capability
+}
+
+// kernelLastCap returns the highest capability the running kernel knows, or
+// CAPLastCap when it cannot be detected
+func kernelLastCap() Capability {
+	lastCap.once.Do(func() {
+		value, err := probeLastCap()
+		if err != nil || value > 63 {
+			value = CAPLastCap
+		}
+		lastCap.value = value
+	})
+	return lastCap.value
+}
+
+// AllCapabilities returns every capability the running kernel supports.
+//
+// Capabilities added by kernels newer than this package are part of the list
+// as Capability(n), named "cap_<n>".
+func AllCapabilities() []Capability {
+	return FullCapSet().List()
+}
+
+// KnownCapabilities returns the capabilities that are both defined by this
+// package and supported by the running kernel.
+func KnownCapabilities() []Capability {
+	return FullCapSet().Intersect(CapSet(1<<(CAPLastCap+1) - 1)).List()
+}
+
+// FullCapSet returns a set holding every capability the running kernel
+// supports
+func FullCapSet() CapSet {
+	return CapSet(1<<(kernelLastCap()+1) - 1)
+}
+
+// selectTypes returns the sets covered by a Select as or'ed Type values
+func selectTypes(set Select) Type {
+	var t Type
+	if set&SelectCaps != 0 {
+		t |= TypeEffective | TypePermitted | TypeInheritable
+	}
+	if set&SelectBounds != 0 {
+		t |= TypeBoundingSet
+	}
+	if set&SelectAmbient != 0 {
+		t |= TypeAmbient
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+//go:build linux
+
+package gocapng
+
+import "testing"
+
+func TestKnownCapabilities(t *testing.T) {
+	all := AllCapabilities()
+	known := KnownCapabilities()
+
+	if len(all) != int(kernelLastCap())+1 {
+		t.Errorf("Expected %d capabilities, got %d", kernelLastCap()+1, len(all))
+	}
+
+	if len(known) > len(all) || len(known) > int(CAPLastCap)+1 {
+		t.Errorf("Expected known capabilities to be limited by the kernel and the table, got %d", len(known))
+	}
+
+	for _, capability := range known {
+		if capability > CAPLastCap {
+			t.Errorf("'%s' is not a known capability", capability)
+		}
+	}
+}
+
+func TestCapSetKernelLimit(t *testing.T) {
+	beyond := kernelLastCap() + 1
+	if !NewCapSet(beyond).Has(beyond) {
+		t.Errorf("Expected %s to be kept whatever the running kernel", beyond)
+	}
+	if NewCapSet(beyond).Intersect(FullCapSet()).Has(beyond) {
+		t.Errorf("Expected %s to be outside of the running kernel", beyond)
+	}
+
+	if FullCapSet().Has(beyond) {
+		t.Errorf("Expected full set not to hold %s", beyond)
+	}
+}
+
+func TestCapabilitySyntheticName(t *testing.T) {
+	if CAPCheckpointRestore.String() != "checkpoint_restore" {
+		t.Errorf("Expected checkpoint_restore, got %s", CAPCheckpointRestore)
+	}
+
+	if (CAPLastCap + 1).String() != "cap_41" {
+		t.Errorf("Expected cap_41, got %s", CAPLastCap+1)
+	}
+}
@@ -0,0 +1,28 @@
+package gocapng
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+var (
+	loggerLock sync.Mutex
+	logger     = log.New(os.Stderr, "gocapng: ", log.LstdFlags)
+)
+
+// SetLogger replaces the logger used to report refused operations, by
+// default the standard error. A nil logger disables logging.
+func SetLogger(l *log.Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+func logf(format string, args ...interface{}) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	if logger != nil {
+		logger.Printf(format, args...)
+	}
+}
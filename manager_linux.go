@@ -0,0 +1,38 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"io"
+	"os"
+)
+
+// Manager is the set of operations of CapNG. Code depending on Manager
+// instead of CapNG can be unit tested without privileges, with the in-memory
+// implementation of the capngfake subpackage.
+type Manager interface {
+	Clear(set Select)
+	Fill(set Select)
+	SetPID(pid int)
+	GetCapsProcess() bool
+	Update(action Act, t Type, capability Capability) bool
+	Updatev(action Act, t Type, capability ...Capability) bool
+	Apply(set Select) error
+	Lock() bool
+	ChangeID(uid, gid int, flag Flags) error
+	GetRootID() int
+	SetRootID(rootID int) bool
+	GetCapsFD(fd os.File) bool
+	ApplyCapsFD(fd os.File) error
+	HaveCapabilities(set Select) Result
+	HavePermittedCapabilities() Result
+	HaveCapability(which Type, capability Capability) bool
+	PrintCapsNumberic(where Print, set Select) string
+	PrintCapsText(where Print, which Type) string
+	WriteCaps(w io.Writer, set Select, format Format) error
+	NameToCapability(name string) (Capability, error)
+	CapabilityToName(capability Capability) string
+	DryRunApply(set Select) (DryRun, error)
+	DryRunChangeID(uid, gid int, flag Flags) (DryRun, error)
+	EnforcePolicyCallingThread(p *Policy) (DryRun, error)
+}
@@ -0,0 +1,394 @@
+package gocapng
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PolicyVersion is the policy file version this package understands
+const PolicyVersion = 1
+
+// Policy describes the privileges of a service. It is usually loaded from a
+// versioned policy file with LoadPolicy:
+//
+//	{
+//	  "version": 1,
+//	  "name": "echo",
+//	  "permitted": ["net_bind_service"],
+//	  "effective": ["net_bind_service"],
+//	  "bounding": [],
+//	  "user": "nobody",
+//	  "securebits": ["noroot", "noroot_locked"],
+//	  "no_new_privs": true
+//	}
+//
+// Capabilities are given by name (see ParseCapability). Sets that are not
+// given are empty, apart from the bounding set which is kept as is unless
+// given.
+type Policy struct {
+	Version int    `json:"version" yaml:"version" toml:"version"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+
+	Effective   []string `json:"effective,omitempty" yaml:"effective,omitempty" toml:"effective,omitempty"`
+	Permitted   []string `json:"permitted,omitempty" yaml:"permitted,omitempty" toml:"permitted,omitempty"`
+	Inheritable []string `json:"inheritable,omitempty" yaml:"inheritable,omitempty" toml:"inheritable,omitempty"`
+	Ambient     []string `json:"ambient,omitempty" yaml:"ambient,omitempty" toml:"ambient,omitempty"`
+	Bounding    []string `json:"bounding" yaml:"bounding" toml:"bounding"`
+
+	// User and Group are names or numeric ids to switch to, empty to keep
+	// the current ones. When only User is given, Group defaults to its
+	// primary group.
+	User  string `json:"user,omitempty" yaml:"user,omitempty" toml:"user,omitempty"`
+	Group string `json:"group,omitempty" yaml:"group,omitempty" toml:"group,omitempty"`
+
+	// Securebits holds names of Securebits.String, empty to keep the current
+	// ones
+	Securebits []string `json:"securebits,omitempty" yaml:"securebits,omitempty" toml:"securebits,omitempty"`
+	NoNewPrivs bool     `json:"no_new_privs,omitempty" yaml:"no_new_privs,omitempty" toml:"no_new_privs,omitempty"`
+}
+
+// ResolvedPolicy is a Policy with names resolved to values
+type ResolvedPolicy struct {
+	Caps Capabilities
+	// KeepBounding is true when the policy does not give a bounding set
+	KeepBounding bool
+
+	// UID and GID are -1 when they are kept
+	UID int
+	GID int
+
+	// Securebits are only changed when SetSecurebits is true
+	Securebits    Securebits
+	SetSecurebits bool
+	NoNewPrivs    bool
+}
+
+// PolicyDecoder decodes a policy file into v, with the same semantics as
+// json.Unmarshal
+type PolicyDecoder func(data []byte, v interface{}) error
+
+var (
+	policyDecodersLock sync.RWMutex
+	policyDecoders     = map[string]PolicyDecoder{
+		"json": json.Unmarshal,
+	}
+)
+
+// RegisterPolicyFormat registers the decoder of a policy file format, named
+// after the file extension without the dot. JSON is supported out of the box.
+// Importing the policyformats subpackage registers YAML and TOML decoders
+// for the flat layout of policy files, and programs needing the full formats
+// can register their own instead, for example:
+//
+//	gocapng.RegisterPolicyFormat("yaml", yaml.Unmarshal)
+//	gocapng.RegisterPolicyFormat("yml", yaml.Unmarshal)
+//	gocapng.RegisterPolicyFormat("toml", toml.Unmarshal)
+//
+// Policy has yaml and toml struct tags using the same keys as JSON.
+func RegisterPolicyFormat(format string, decode PolicyDecoder) {
+	policyDecodersLock.Lock()
+	defer policyDecodersLock.Unlock()
+	policyDecoders[strings.ToLower(format)] = decode
+}
+
+// LoadPolicy reads and validates a policy file, choosing the format from the
+// file extension
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ParsePolicy(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy decodes and validates a policy in the given format: "json", or
+// a format registered with RegisterPolicyFormat, such as "yaml", "yml" and
+// "toml" once the policyformats subpackage is imported
+func ParsePolicy(data []byte, format string) (*Policy, error) {
+	policyDecodersLock.RLock()
+	decode, ok := policyDecoders[strings.ToLower(format)]
+	policyDecodersLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: %q, import github.com/ik5/gocapng/policyformats for YAML and TOML, or see RegisterPolicyFormat",
+			ErrUnsupportedPolicyFormat, format,
+		)
+	}
+
+	var policy Policy
+	if err := decode(data, &policy); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+	if _, err := policy.Resolve(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Resolve validates the policy and resolves its names. Capabilities the
+// running kernel does not support make the policy invalid, as the process
+// could never be reduced to it.
+func (p *Policy) Resolve() (ResolvedPolicy, error) {
+	resolved := ResolvedPolicy{
+		KeepBounding: p.Bounding == nil,
+		UID:          -1,
+		GID:          -1,
+		NoNewPrivs:   p.NoNewPrivs,
+	}
+	invalid := func(format string, args ...interface{}) (ResolvedPolicy, error) {
+		return ResolvedPolicy{}, fmt.Errorf("%w: %s", ErrInvalidPolicy, fmt.Sprintf(format, args...))
+	}
+
+	if p.Version != PolicyVersion {
+		return invalid("unsupported version %d, expected %d", p.Version, PolicyVersion)
+	}
+
+	sets := []struct {
+		t     Type
+		names []string
+	}{
+		{TypeEffective, p.Effective},
+		{TypePermitted, p.Permitted},
+		{TypeInheritable, p.Inheritable},
+		{TypeAmbient, p.Ambient},
+		{TypeBoundingSet, p.Bounding},
+	}
+	supported := FullCapSet()
+	for _, set := range sets {
+		var caps CapSet
+		for _, name := range set.names {
+			capability, err := ParseCapability(name)
+			if err != nil {
+				return invalid("%s: %s: %s", set.t, name, err)
+			}
+			if !supported.Has(capability) {
+				return invalid("%s: %s: not supported by the running kernel", set.t, name)
+			}
+			caps = caps.Add(capability)
+		}
+		resolved.Caps.Set(set.t, caps)
+	}
+
+	caps := resolved.Caps
+	if !caps.Effective.IsSubset(caps.Permitted) {
+		return invalid("effective %s not in permitted", caps.Effective.Difference(caps.Permitted))
+	}
+	if !caps.Ambient.IsSubset(caps.Permitted.Intersect(caps.Inheritable)) {
+		return invalid("ambient %s not in both permitted and inheritable",
+			caps.Ambient.Difference(caps.Permitted.Intersect(caps.Inheritable)))
+	}
+
+	if p.Securebits != nil {
+		bits, err := ParseSecurebits(p.Securebits...)
+		if err != nil {
+			return invalid("%s", err)
+		}
+		resolved.Securebits, resolved.SetSecurebits = bits, true
+	}
+
+	var err error
+	if p.User != "" {
+		if resolved.UID, resolved.GID, err = lookupUser(p.User); err != nil {
+			return invalid("user %s: %s", p.User, err)
+		}
+	}
+	if p.Group != "" {
+		if resolved.GID, err = lookupGroup(p.Group); err != nil {
+			return invalid("group %s: %s", p.Group, err)
+		}
+	}
+
+	// ChangeID manages keep_caps itself
+	if resolved.SetSecurebits && (resolved.UID != -1 || resolved.GID != -1) &&
+		resolved.Securebits&(SecureKeepCaps|SecureKeepCapsLocked) != 0 {
+		return invalid("keep_caps and keep_caps_locked cannot be combined with user or group")
+	}
+
+	return resolved, nil
+}
+
+// types returns the sets the policy sets
+func (r ResolvedPolicy) types() Type {
+	t := TypeEffective | TypePermitted | TypeInheritable | TypeAmbient
+	if !r.KeepBounding {
+		t |= TypeBoundingSet
+	}
+	return t
+}
+
+// PolicyDiff holds the differences between a process and a policy
+type PolicyDiff struct {
+	// Missing holds the capabilities of the policy the process does not have,
+	// Extra the capabilities the process has outside of the policy
+	Missing Capabilities
+	Extra   Capabilities
+
+	UID        bool
+	GID        bool
+	Securebits bool
+	NoNewPrivs bool
+}
+
+// Empty returns true if the process matches the policy
+func (d PolicyDiff) Empty() bool {
+	return d.Missing == Capabilities{} && d.Extra == Capabilities{} &&
+		!d.UID && !d.GID && !d.Securebits && !d.NoNewPrivs
+}
+
+func (d PolicyDiff) String() string {
+	var parts []string
+	for _, t := range allTypes {
+		if missing := d.Missing.Get(t); !missing.IsEmpty() {
+			parts = append(parts, fmt.Sprintf("%s missing %s", t, missing))
+		}
+		if extra := d.Extra.Get(t); !extra.IsEmpty() {
+			parts = append(parts, fmt.Sprintf("%s extra %s", t, extra))
+		}
+	}
+	for _, field := range []struct {
+		differ bool
+		name   string
+	}{
+		{d.UID, "uid"}, {d.GID, "gid"}, {d.Securebits, "securebits"}, {d.NoNewPrivs, "no_new_privs"},
+	} {
+		if field.differ {
+			parts = append(parts, field.name+" differs")
+		}
+	}
+
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Diff compares state with the policy
+func (r ResolvedPolicy) Diff(state ProcessState) PolicyDiff {
+	var diff PolicyDiff
+	for _, t := range allTypes {
+		if t == TypeBoundingSet && r.KeepBounding {
+			continue
+		}
+		want, have := r.Caps.Get(t), state.Caps.Get(t)
+		diff.Missing.Set(t, want.Difference(have))
+		diff.Extra.Set(t, have.Difference(want))
+	}
+
+	diff.UID = r.UID != -1 && state.UID != IDs{Real: r.UID, Effective: r.UID, Saved: r.UID, FS: r.UID}
+	diff.GID = r.GID != -1 && state.GID != IDs{Real: r.GID, Effective: r.GID, Saved: r.GID, FS: r.GID}
+	diff.Securebits = r.SetSecurebits && state.Securebits != r.Securebits
+	diff.NoNewPrivs = r.NoNewPrivs && !state.NoNewPrivs
+
+	return diff
+}
+
+// Check returns an error when state lacks the privileges needed to reduce it
+// to the policy: a capability the policy requires that the process cannot
+// gain, or one of the capabilities the changes use (see privileges). They
+// only need to be permitted, the enforcement raises them into the effective
+// set first.
+func (r ResolvedPolicy) Check(state ProcessState) error {
+	if r.SetSecurebits && r.Securebits != state.Securebits {
+		if !state.Caps.Permitted.Has(CAPSetPCap) {
+			return requirementError(OpSetSecurebits, ErrSettingSecurebitsFailed)
+		}
+		locked := state.Securebits & (SecureNoRootLocked | SecureNoSetUIDFixupLocked |
+			SecureKeepCapsLocked | SecureNoCapAmbientRaiseLocked)
+		// every locked bit follows the bit it locks
+		if (r.Securebits^state.Securebits)&(locked|locked>>1) != 0 {
+			return fmt.Errorf("%w: %s are locked", ErrSettingSecurebitsFailed, locked)
+		}
+	}
+
+	required := r.Caps
+	if r.KeepBounding {
+		required.Bounding = 0
+	}
+	required.Permitted = required.Permitted.Union(r.privileges(state))
+
+	// capset(2) raises effective and inheritable capabilities from the
+	// permitted set, and ambient ones from both
+	available := state.Caps
+	available.Effective = available.Permitted
+	available.Inheritable = available.Inheritable.Union(available.Permitted)
+	available.Ambient = available.Permitted.Intersect(available.Inheritable)
+
+	missing := &CapabilityCheckError{Err: ErrMissingCapabilities, Sets: map[Type]CapSet{}}
+	for _, t := range allTypes {
+		if caps := required.Get(t).Difference(available.Get(t)); !caps.IsEmpty() {
+			missing.Sets[t] = caps
+		}
+	}
+	if len(missing.Sets) > 0 {
+		return missing
+	}
+	return nil
+}
+
+// privileges returns the capabilities, besides the ones of the policy, used to
+// reduce state to the policy: setpcap to shrink the bounding set or to change
+// the securebits, setgid to switch user or group, as the supplementary groups
+// are dropped too, and setuid to switch user
+func (r ResolvedPolicy) privileges(state ProcessState) CapSet {
+	var caps CapSet
+	if !r.KeepBounding && !state.Caps.Bounding.IsSubset(r.Caps.Bounding) {
+		caps = caps.Add(CAPSetPCap)
+	}
+	if r.SetSecurebits && r.Securebits != state.Securebits {
+		caps = caps.Add(CAPSetPCap)
+	}
+	if r.UID != -1 || r.GID != -1 {
+		caps = caps.Add(CAPSetGID)
+	}
+	if r.UID != -1 {
+		caps = caps.Add(CAPSetUID)
+	}
+	return caps
+}
+
+// lookupUser returns the uid and primary gid of name, which may be numeric
+func lookupUser(name string) (int, int, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		uid, convErr := strconv.Atoi(name)
+		if convErr != nil {
+			return -1, -1, err
+		}
+		if u, err = user.LookupId(name); err != nil {
+			return uid, -1, nil
+		}
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return -1, -1, err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return -1, -1, err
+	}
+	return uid, gid, nil
+}
+
+// lookupGroup returns the gid of name, which may be numeric
+func lookupGroup(name string) (int, error) {
+	if gid, err := strconv.Atoi(name); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(g.Gid)
+}
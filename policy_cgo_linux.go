@@ -0,0 +1,132 @@
+//go:build linux && cgo
+
+package gocapng
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// EnforcePolicyCallingThread reduces the calling thread to exactly the
+// policy, and returns the state before and after, for auditing.
+//
+// Before changing anything, EnforcePolicyCallingThread refuses (see
+// ResolvedPolicy.Check) when the process lacks something the policy requires.
+// The capabilities the changes use are raised into the effective set first,
+// then the bounding set is applied, then the securebits, then the
+// capabilities, through ChangeID when the policy switches user or group, and
+// finally no_new_privs.
+// When the state does not match the policy afterwards, ErrPolicyNotEnforced is
+// returned with the differences.
+//
+// Like Apply, libcap-ng only changes the calling thread, and the Go runtime
+// runs goroutines on several threads: the other threads keep their
+// privileges. Once the thread was changed, even partly, the calling goroutine
+// stays locked to it (see runtime.LockOSThread), so that it never moves back
+// to a thread holding more privileges. Use EnforcePolicyAllThreads to reduce
+// the whole process.
+func (cp CapNG) EnforcePolicyCallingThread(p *Policy) (DryRun, error) {
+	run := DryRun{Operation: fmt.Sprintf("EnforcePolicyCallingThread(%s)", p.Name)}
+
+	target, err := p.Resolve()
+	if err != nil {
+		return run, err
+	}
+
+	runtime.LockOSThread()
+	changed := false
+	defer func() {
+		if !changed {
+			runtime.UnlockOSThread()
+		}
+	}()
+
+	run.Before, err = readThreadState()
+	if err != nil {
+		return run, err
+	}
+	run.After = run.Before
+
+	if err := target.Check(run.Before); err != nil {
+		return run, err
+	}
+	if err := ratchetAllows(run.Operation, target.types(), target.Caps); err != nil {
+		return run, err
+	}
+
+	bounding := target.Caps.Bounding
+	if target.KeepBounding {
+		bounding = run.Before.Caps.Bounding
+	}
+	cp.Clear(SelectAll)
+	for _, set := range []struct {
+		t    Type
+		caps CapSet
+	}{
+		{TypeEffective, target.Caps.Effective},
+		{TypePermitted, target.Caps.Permitted},
+		{TypeInheritable, target.Caps.Inheritable},
+		{TypeAmbient, target.Caps.Ambient},
+		{TypeBoundingSet, bounding},
+	} {
+		for _, capability := range set.caps.List() {
+			if !cp.Update(ActAdd, set.t, capability) {
+				return run, fmt.Errorf("%w: %s %s", ErrInvalidPolicy, set.t, capability)
+			}
+		}
+	}
+
+	enforce := func() error {
+		if err := raisePrivileges(target, run.Before, false); err != nil {
+			return err
+		}
+
+		if !target.KeepBounding {
+			if err := cp.Apply(SelectBounds); err != nil {
+				return err
+			}
+		}
+
+		if target.SetSecurebits && target.Securebits != run.Before.Securebits {
+			_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSecurebits, uintptr(target.Securebits), 0)
+			if errno != 0 {
+				return fmt.Errorf("%w: %s", ErrSettingSecurebitsFailed, errno)
+			}
+		}
+
+		if target.UID != -1 || target.GID != -1 {
+			if err := cp.ChangeID(target.UID, target.GID, FlagsDropSuppGrp); err != nil {
+				return err
+			}
+			if err := cp.Apply(SelectAmbient); err != nil {
+				return err
+			}
+		} else if err := cp.Apply(SelectCaps | SelectAmbient); err != nil {
+			return err
+		}
+
+		if target.NoNewPrivs {
+			_, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0)
+			if errno != 0 {
+				return errno
+			}
+		}
+		return nil
+	}
+	changed = true
+	enforceErr := enforce()
+
+	if run.After, err = readThreadState(); err != nil {
+		return run, err
+	}
+	if enforceErr != nil {
+		return run, enforceErr
+	}
+
+	if diff := target.Diff(run.After); !diff.Empty() {
+		return run, fmt.Errorf("%w: %s", ErrPolicyNotEnforced, diff)
+	}
+	tightenRatchet(target.types(), target.Caps)
+	return run, nil
+}
@@ -0,0 +1,199 @@
+package gocapng
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"version": 1,
+		"name": "echo",
+		"permitted": ["net_bind_service", "CAP_NET_RAW"],
+		"effective": ["net_bind_service"],
+		"bounding": [],
+		"user": "0",
+		"group": "0",
+		"securebits": ["noroot", "noroot_locked"],
+		"no_new_privs": true
+	}`), "json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	resolved, err := policy.Resolve()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved.Caps.Permitted != NewCapSet(CAPNetBindService, CAPNetRaw) ||
+		resolved.Caps.Effective != NewCapSet(CAPNetBindService) {
+		t.Errorf("Unexpected capabilities %+v", resolved.Caps)
+	}
+	if resolved.KeepBounding || resolved.Caps.Bounding != 0 {
+		t.Error("Expected the bounding set to be cleared")
+	}
+	if resolved.UID != 0 || resolved.GID != 0 {
+		t.Errorf("Expected uid and gid 0, got %d and %d", resolved.UID, resolved.GID)
+	}
+	if !resolved.SetSecurebits || resolved.Securebits != SecureNoRoot|SecureNoRootLocked {
+		t.Errorf("Unexpected securebits %s", resolved.Securebits)
+	}
+
+	toCheck := []struct {
+		name   string
+		policy string
+		format string
+		err    error
+	}{
+		{
+			name:   "version",
+			policy: `{"version": 2}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "unknown capability",
+			policy: `{"version": 1, "permitted": ["fly"]}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "capability unknown to the kernel",
+			policy: `{"version": 1, "permitted": ["cap_63"]}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "effective not permitted",
+			policy: `{"version": 1, "effective": ["net_raw"]}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "ambient not inheritable",
+			policy: `{"version": 1, "permitted": ["net_raw"], "ambient": ["net_raw"]}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "unknown securebit",
+			policy: `{"version": 1, "securebits": ["nothing"]}`,
+			format: "json",
+			err:    ErrInvalidPolicy,
+		},
+		{
+			name:   "yaml",
+			policy: `version: 1`,
+			format: "yaml",
+			err:    ErrUnsupportedPolicyFormat,
+		},
+	}
+
+	for _, check := range toCheck {
+		if _, err := ParsePolicy([]byte(check.policy), check.format); !errors.Is(err, check.err) {
+			t.Errorf("'%s' expected to fail with '%s' but have '%v' instead", check.name, check.err, err)
+		}
+	}
+}
+
+func TestRegisterPolicyFormat(t *testing.T) {
+	RegisterPolicyFormat("test", func(data []byte, v interface{}) error {
+		policy := v.(*Policy)
+		policy.Version = PolicyVersion
+		policy.Permitted = strings.Fields(string(data))
+		return nil
+	})
+
+	policy, err := ParsePolicy([]byte("net_raw kill"), "TEST")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(policy.Permitted) != 2 {
+		t.Errorf("Expected the registered decoder to be used, got %+v", policy)
+	}
+}
+
+func TestPolicyDiffAndCheck(t *testing.T) {
+	policy := Policy{
+		Version:   PolicyVersion,
+		Permitted: []string{"net_bind_service"},
+		Effective: []string{"net_bind_service"},
+	}
+	resolved, err := policy.Resolve()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	state := ProcessState{
+		Caps: Capabilities{
+			Effective: NewCapSet(CAPNetRaw),
+			Permitted: NewCapSet(CAPNetRaw, CAPNetBindService),
+			Bounding:  NewCapSet(CAPNetRaw, CAPNetBindService),
+		},
+	}
+
+	diff := resolved.Diff(state)
+	if diff.Empty() {
+		t.Fatal("Expected differences")
+	}
+	if diff.Missing.Effective != NewCapSet(CAPNetBindService) ||
+		diff.Extra.Effective != NewCapSet(CAPNetRaw) || diff.Extra.Permitted != NewCapSet(CAPNetRaw) {
+		t.Errorf("Unexpected diff %s", diff)
+	}
+	if diff.Extra.Bounding != 0 {
+		t.Errorf("Expected the bounding set to be kept, got %s", diff)
+	}
+
+	if err := resolved.Check(state); err != nil {
+		t.Errorf("Expected the policy to be reachable, got %s", err)
+	}
+
+	state.Caps.Permitted = NewCapSet(CAPNetRaw)
+	var checkErr *CapabilityCheckError
+	if err := resolved.Check(state); !errors.As(err, &checkErr) ||
+		checkErr.Sets[TypePermitted] != NewCapSet(CAPNetBindService) {
+		t.Errorf("Expected net_bind_service to be missing, got %v", err)
+	}
+
+	switchUser := resolved
+	switchUser.UID = 65534
+	state.Caps.Permitted = NewCapSet(CAPNetBindService, CAPSetUID)
+	if err := switchUser.Check(state); !errors.As(err, &checkErr) ||
+		checkErr.Sets[TypePermitted] != NewCapSet(CAPSetGID) {
+		t.Errorf("Expected setgid to be missing, got %v", err)
+	}
+	switchUser.UID, switchUser.GID = -1, 65534
+	state.Caps.Permitted = NewCapSet(CAPNetBindService, CAPSetGID)
+	if err := switchUser.Check(state); err != nil {
+		t.Errorf("Expected the group switch to be reachable, got %s", err)
+	}
+
+	shrink := resolved
+	shrink.KeepBounding, shrink.Caps.Bounding = false, NewCapSet(CAPNetBindService)
+	state.Caps.Permitted = NewCapSet(CAPNetBindService)
+	if err := shrink.Check(state); !errors.As(err, &checkErr) ||
+		checkErr.Sets[TypePermitted] != NewCapSet(CAPSetPCap) {
+		t.Errorf("Expected setpcap to be missing to shrink the bounding set, got %v", err)
+	}
+	state.Caps.Permitted = NewCapSet(CAPNetBindService, CAPSetPCap)
+	if err := shrink.Check(state); err != nil {
+		t.Errorf("Expected a permitted setpcap to be enough, got %s", err)
+	}
+	shrink.Caps.Bounding = state.Caps.Bounding
+	state.Caps.Permitted = NewCapSet(CAPNetBindService)
+	if err := shrink.Check(state); err != nil {
+		t.Errorf("Expected an unchanged bounding set not to need setpcap, got %s", err)
+	}
+
+	resolved.SetSecurebits, resolved.Securebits = true, SecureNoRoot
+	if err := resolved.Check(state); !errors.Is(err, ErrSettingSecurebitsFailed) {
+		t.Errorf("Expected %s, got %v", ErrSettingSecurebitsFailed, err)
+	}
+
+	state.Caps.Permitted = NewCapSet(CAPNetBindService, CAPSetPCap)
+	state.Securebits = SecureNoRootLocked
+	if err := resolved.Check(state); !errors.Is(err, ErrSettingSecurebitsFailed) {
+		t.Errorf("Expected locked securebits to fail, got %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux && cgo
+
+package gocapng
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEnforcePolicyAllThreadsNeedsNoCgo(t *testing.T) {
+	_, err := EnforcePolicyAllThreads(&Policy{Version: PolicyVersion})
+	if !errors.Is(err, ErrAllThreadsUnsupported) {
+		t.Errorf("Expected %s, got %v", ErrAllThreadsUnsupported, err)
+	}
+}
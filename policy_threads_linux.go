@@ -0,0 +1,244 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	prSetKeepCaps          = 8
+	prCapBSetDrop          = 24
+	prCapAmbientRaise      = 2
+	prCapAmbientClearAll   = 4
+	linuxCapabilityVersion = 0x20080522
+)
+
+// capUserHeader and capUserData are the structures of capset(2)
+type capUserHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capUserData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+// EnforcePolicyAllThreads is like CapNG.EnforcePolicyCallingThread, but changes every
+// thread of the process instead of the calling thread only, without going
+// through libcap-ng.
+//
+// It relies on syscall.AllThreadsSyscall, which the Go runtime only supports
+// in programs built without cgo (CGO_ENABLED=0). Otherwise nothing is changed
+// and ErrAllThreadsUnsupported is returned.
+//
+// After the changes, the state of every thread is compared with the policy,
+// and ErrPolicyNotEnforced is returned for the first one that differs.
+func EnforcePolicyAllThreads(p *Policy) (DryRun, error) {
+	run := DryRun{Operation: fmt.Sprintf("EnforcePolicyAllThreads(%s)", p.Name)}
+
+	target, err := p.Resolve()
+	if err != nil {
+		return run, err
+	}
+
+	_, _, errno := syscall.AllThreadsSyscall(syscall.SYS_PRCTL, prGetSecurebits, 0, 0)
+	if errno == syscall.ENOTSUP {
+		return run, ErrAllThreadsUnsupported
+	}
+
+	run.Before, err = ReadProcessState(0)
+	if err != nil {
+		return run, err
+	}
+	run.After = run.Before
+
+	if err := target.Check(run.Before); err != nil {
+		return run, err
+	}
+	if err := ratchetAllows(run.Operation, target.types(), target.Caps); err != nil {
+		return run, err
+	}
+
+	enforceErr := enforceAllThreads(target, run.Before)
+
+	if run.After, err = ReadProcessState(0); err != nil {
+		return run, err
+	}
+	if enforceErr != nil {
+		return run, enforceErr
+	}
+
+	if err := checkAllThreads(target); err != nil {
+		return run, err
+	}
+	tightenRatchet(target.types(), target.Caps)
+	return run, nil
+}
+
+// enforceAllThreads follows the steps of CapNG.EnforcePolicyCallingThread
+// with system calls made on every thread
+func enforceAllThreads(target ResolvedPolicy, before ProcessState) error {
+	prctl := func(option, arg uintptr) error {
+		if _, _, errno := syscall.AllThreadsSyscall(syscall.SYS_PRCTL, option, arg, 0); errno != 0 {
+			return errno
+		}
+		return nil
+	}
+
+	if err := raisePrivileges(target, before, true); err != nil {
+		return err
+	}
+
+	if !target.KeepBounding {
+		for _, capability := range before.Caps.Bounding.Difference(target.Caps.Bounding).List() {
+			if err := prctl(prCapBSetDrop, uintptr(capability)); err != nil {
+				return permissionError(OpDropBoundingSet, fmt.Errorf("%w: %s", ErrClearingBoundingSet, err), err)
+			}
+		}
+	}
+
+	if target.SetSecurebits && target.Securebits != before.Securebits {
+		if err := prctl(prSetSecurebits, uintptr(target.Securebits)); err != nil {
+			return fmt.Errorf("%w: %s", ErrSettingSecurebitsFailed, err)
+		}
+	}
+
+	if target.UID != -1 || target.GID != -1 {
+		if err := prctl(prSetKeepCaps, 1); err != nil {
+			return fmt.Errorf("%w: %s", ErrFailureRequestingCapabilitiesUidChange, err)
+		}
+		// the syscall package changes the ids of every thread
+		if err := syscall.Setgroups(nil); err != nil {
+			return permissionError(OpSetGroups, fmt.Errorf("%w: %s", ErrDroppingSupplementalGroupsFailed, err), err)
+		}
+		if target.GID != -1 {
+			if err := syscall.Setresgid(target.GID, target.GID, target.GID); err != nil {
+				return permissionError(OpChangeGID, fmt.Errorf("%w: %s", ErrChangingGIDFailed, err), err)
+			}
+		}
+		if target.UID != -1 {
+			if err := syscall.Setresuid(target.UID, target.UID, target.UID); err != nil {
+				return permissionError(OpChangeUID, fmt.Errorf("%w: %s", ErrChangingUIDFailed, err), err)
+			}
+		}
+		if err := prctl(prSetKeepCaps, 0); err != nil {
+			return fmt.Errorf("%w: %s", ErrDroppingAbilityRetainUIDChangeFailed, err)
+		}
+	}
+
+	if err := setThreadCaps(target.Caps, true); err != nil {
+		return fmt.Errorf("%w: %s", ErrSelectCapsCapsetSyscall, err)
+	}
+
+	if _, _, errno := syscall.AllThreadsSyscall6(
+		syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0,
+	); errno != 0 && errno != syscall.EINVAL {
+		return fmt.Errorf("%w: %s", ErrSelectAmbientProcessCapabilitiesClearing, errno)
+	}
+	for _, capability := range target.Caps.Ambient.List() {
+		if _, _, errno := syscall.AllThreadsSyscall6(
+			syscall.SYS_PRCTL, prCapAmbient, prCapAmbientRaise, uintptr(capability), 0, 0, 0,
+		); errno != 0 {
+			return fmt.Errorf("%w: %s", ErrSelectAmbientProcessCapabilitiesSetting, errno)
+		}
+	}
+
+	if target.NoNewPrivs {
+		if _, _, errno := syscall.AllThreadsSyscall6(
+			syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0,
+		); errno != 0 {
+			return errno
+		}
+	}
+
+	return nil
+}
+
+// raisePrivileges raises the capabilities used to reduce before to target
+// (see ResolvedPolicy.privileges) into the effective set of the calling
+// thread, or of every thread with allThreads
+func raisePrivileges(target ResolvedPolicy, before ProcessState, allThreads bool) error {
+	raise := target.privileges(before).Difference(before.Caps.Effective)
+	if raise.IsEmpty() {
+		return nil
+	}
+
+	caps := before.Caps
+	caps.Effective = caps.Effective.Union(raise)
+	if err := setThreadCaps(caps, allThreads); err != nil {
+		return fmt.Errorf("%w: %s", ErrSelectCapsCapsetSyscall, err)
+	}
+	return nil
+}
+
+// setThreadCaps sets the effective, permitted and inheritable sets of caps on
+// the calling thread, or on every thread with allThreads
+func setThreadCaps(caps Capabilities, allThreads bool) error {
+	header := capUserHeader{version: linuxCapabilityVersion}
+	var data [2]capUserData
+	for i := range data {
+		shift := 32 * uint(i)
+		data[i] = capUserData{
+			effective:   uint32(caps.Effective >> shift),
+			permitted:   uint32(caps.Permitted >> shift),
+			inheritable: uint32(caps.Inheritable >> shift),
+		}
+	}
+
+	var errno syscall.Errno
+	if allThreads {
+		_, _, errno = syscall.AllThreadsSyscall(
+			syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0,
+		)
+	} else {
+		_, _, errno = syscall.RawSyscall(
+			syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0,
+		)
+	}
+	runtime.KeepAlive(&header)
+	runtime.KeepAlive(&data)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// checkAllThreads compares every thread of the process with target
+func checkAllThreads(target ResolvedPolicy) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		state, err := readProcessState(fmt.Sprintf("/proc/self/task/%d/status", tid), os.Getpid(), false)
+		if errors.Is(err, os.ErrNotExist) {
+			// the thread exited
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// securebits can only be read for the calling thread, and are
+		// set on every thread by the same call as the others
+		state.Securebits = target.Securebits
+		if diff := target.Diff(state); !diff.Empty() {
+			return fmt.Errorf("%w: thread %d: %s", ErrPolicyNotEnforced, tid, diff)
+		}
+	}
+	return nil
+}
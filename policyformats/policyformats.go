@@ -0,0 +1,174 @@
+//go:build linux
+
+// Package policyformats registers YAML and TOML decoders for capability
+// policy files (see gocapng.RegisterPolicyFormat) when imported for its side
+// effect:
+//
+//	import _ "github.com/ik5/gocapng/policyformats"
+//
+// As gocapng has no dependencies, the decoders are not complete YAML and TOML
+// parsers: they read the flat layout of policy files, keys holding a string,
+// an integer, a boolean or a list of strings, and refuse anything else, such
+// as nested mappings, tables or multi-line strings:
+//
+//	# YAML
+//	version: 1
+//	name: echo
+//	permitted: [net_bind_service]
+//	bounding:
+//	  - net_bind_service
+//	no_new_privs: true
+//
+//	# TOML
+//	version = 1
+//	name = "echo"
+//	permitted = ["net_bind_service"]
+//	bounding = []
+//	no_new_privs = true
+//
+// Programs needing the full formats can register the decoders of their YAML
+// and TOML libraries instead, under the same names.
+package policyformats
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ik5/gocapng"
+)
+
+func init() {
+	gocapng.RegisterPolicyFormat("yaml", UnmarshalYAML)
+	gocapng.RegisterPolicyFormat("yml", UnmarshalYAML)
+	gocapng.RegisterPolicyFormat("toml", UnmarshalTOML)
+}
+
+// document holds the keys of a decoded file, before being converted to v
+// through its JSON struct tags
+type document map[string]interface{}
+
+func (d document) set(line int, key string, value interface{}) error {
+	if key == "" {
+		return fmt.Errorf("line %d: missing key", line)
+	}
+	if _, ok := d[key]; ok {
+		return fmt.Errorf("line %d: duplicate key %q", line, key)
+	}
+	d[key] = value
+	return nil
+}
+
+// decode stores d into v, with the semantics of json.Unmarshal
+func (d document) decode(v interface{}) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stripComment removes the comment of line, outside of quoted strings. YAML
+// comments must follow a space, TOML ones may start anywhere.
+func stripComment(line string, afterSpace bool) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (!afterSpace || i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitList splits the items of a flow list, "[a, b]", outside of quoted
+// strings
+func splitList(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("invalid list %s", value)
+	}
+	value = strings.TrimSpace(value[1 : len(value)-1])
+
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			return nil, fmt.Errorf("nested values are not supported: %s", value)
+		case c == ',':
+			items = append(items, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in %s", value)
+	}
+	// a trailing comma is allowed
+	if last := strings.TrimSpace(value[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items, nil
+}
+
+// parseList parses a flow list of strings with parse
+func parseList(value string, parse func(string) (interface{}, error)) ([]string, error) {
+	items, err := splitList(value)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []string{}
+	for _, item := range items {
+		parsed, err := parse(item)
+		if err != nil {
+			return nil, err
+		}
+		text, ok := parsed.(string)
+		if !ok {
+			return nil, fmt.Errorf("only lists of strings are supported: %s", item)
+		}
+		list = append(list, text)
+	}
+	return list, nil
+}
+
+// unquote parses a double quoted string, with the escapes of Go, or a single
+// quoted one, where escape doubles the quote in YAML and is empty in TOML
+func unquote(value string, escape string) (string, bool, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		text, err := strconv.Unquote(value)
+		return text, true, err
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		text := value[1 : len(value)-1]
+		if escape != "" {
+			text = strings.ReplaceAll(text, escape, "'")
+		} else if strings.Contains(text, "'") {
+			return "", true, fmt.Errorf("invalid string %s", value)
+		}
+		return text, true, nil
+	case strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'"):
+		return "", true, fmt.Errorf("unterminated string %s", value)
+	}
+	return "", false, nil
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package policyformats
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ik5/gocapng"
+)
+
+func TestParsePolicy(t *testing.T) {
+	expected := gocapng.Policy{
+		Version:    gocapng.PolicyVersion,
+		Name:       "echo # server",
+		Permitted:  []string{"net_bind_service", "net_raw"},
+		Effective:  []string{"net_bind_service"},
+		Bounding:   []string{},
+		User:       "nobody",
+		Securebits: []string{"noroot", "noroot_locked"},
+		NoNewPrivs: true,
+	}
+
+	toCheck := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{
+			name:   "yaml",
+			format: "yaml",
+			data: `---
+# the echo server
+version: 1
+name: "echo # server"
+permitted:
+  - net_bind_service
+  - 'net_raw'   # for ping
+effective: [net_bind_service]
+bounding: []
+user: nobody
+securebits: [noroot, "noroot_locked"]
+no_new_privs: true
+`,
+		},
+		{
+			name:   "yml",
+			format: "yml",
+			data: `version: 1
+name: 'echo # server'
+permitted: [net_bind_service, net_raw]
+effective:
+- net_bind_service
+bounding: []
+user: nobody
+securebits:
+  - noroot
+  - noroot_locked
+no_new_privs: true
+`,
+		},
+		{
+			name:   "toml",
+			format: "toml",
+			data: `# the echo server
+version = 1
+name = "echo # server"
+permitted = [
+  "net_bind_service",
+  'net_raw', # for ping
+]
+effective = ["net_bind_service"]
+bounding = []
+user = "nobody"
+securebits = ["noroot", "noroot_locked"]
+no_new_privs = true
+`,
+		},
+	}
+
+	for _, check := range toCheck {
+		policy, err := gocapng.ParsePolicy([]byte(check.data), check.format)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*policy, expected) {
+			t.Errorf("'%s' expected %+v but have %+v instead", check.name, expected, *policy)
+		}
+	}
+}
+
+func TestUnmarshalYAMLNull(t *testing.T) {
+	var policy gocapng.Policy
+	if err := UnmarshalYAML([]byte("version: 1\nbounding:\nuser: nobody\n"), &policy); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if policy.Bounding != nil || policy.User != "nobody" {
+		t.Errorf("Expected a kept bounding set, have %+v", policy)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	toCheck := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{name: "yaml nested mapping", format: "yaml", data: "version: 1\nuser:\n  name: nobody\n"},
+		{name: "yaml duplicate key", format: "yaml", data: "version: 1\nversion: 1\n"},
+		{name: "yaml nested list", format: "yaml", data: "version: 1\npermitted: [[net_raw]]\n"},
+		{name: "yaml block scalar", format: "yaml", data: "version: 1\nname: |\n  echo\n"},
+		{name: "yaml 1.1 boolean", format: "yaml", data: "version: 1\nno_new_privs: yes\n"},
+		{name: "yaml list outside of a list", format: "yaml", data: "- net_raw\n"},
+		{name: "toml table", format: "toml", data: "version = 1\n[user]\nname = \"nobody\"\n"},
+		{name: "toml bare string", format: "toml", data: "version = 1\nuser = nobody\n"},
+		{name: "toml unterminated array", format: "toml", data: "version = 1\npermitted = [\n\"net_raw\"\n"},
+		{name: "toml multi-line string", format: "toml", data: "version = 1\nname = \"\"\"echo\"\"\"\n"},
+		{name: "toml dotted key", format: "toml", data: "version = 1\nuser.name = \"nobody\"\n"},
+	}
+
+	for _, check := range toCheck {
+		if _, err := gocapng.ParsePolicy([]byte(check.data), check.format); !errors.Is(err, gocapng.ErrInvalidPolicy) {
+			t.Errorf("'%s' expected %s but have %v instead", check.name, gocapng.ErrInvalidPolicy, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package policyformats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalTOML decodes the flat TOML layout of policy files into v, with the
+// semantics of json.Unmarshal
+func UnmarshalTOML(data []byte, v interface{}) error {
+	doc := document{}
+
+	// pending holds an array spanning several lines, from line start
+	var pending string
+	var start int
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(stripComment(scanner.Text(), false))
+		if pending != "" {
+			pending += " " + line
+			if !strings.HasSuffix(line, "]") {
+				continue
+			}
+			line, pending = pending, ""
+		} else {
+			if line == "" {
+				continue
+			}
+			if strings.HasPrefix(line, "[") {
+				return fmt.Errorf("line %d: tables are not supported", number)
+			}
+			start = number
+		}
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return fmt.Errorf("line %d: expected \"key = value\"", number)
+		}
+		name := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if strings.ContainsAny(name, " \t.\"'") {
+			return fmt.Errorf("line %d: only bare keys are supported", number)
+		}
+
+		if strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") {
+			pending = line
+			continue
+		}
+
+		var parsed interface{}
+		var err error
+		if strings.HasPrefix(value, "[") {
+			parsed, err = parseList(value, parseTOMLScalar)
+		} else {
+			parsed, err = parseTOMLScalar(value)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", start, err)
+		}
+		if err := doc.set(start, name, parsed); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if pending != "" {
+		return fmt.Errorf("line %d: unterminated array", start)
+	}
+	return doc.decode(v)
+}
+
+// parseTOMLScalar parses a string, an integer or a boolean
+func parseTOMLScalar(value string) (interface{}, error) {
+	if strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''") {
+		return nil, fmt.Errorf("multi-line strings are not supported: %s", value)
+	}
+	if text, quoted, err := unquote(value, ""); quoted {
+		return text, err
+	}
+
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if number, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return number, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", value)
+}
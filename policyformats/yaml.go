@@ -0,0 +1,123 @@
+//go:build linux
+
+package policyformats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalYAML decodes the flat YAML layout of policy files into v, with the
+// semantics of json.Unmarshal
+func UnmarshalYAML(data []byte, v interface{}) error {
+	doc := document{}
+
+	// key holds the key of a block list being read, "- item" lines
+	var key string
+	var list []string
+
+	// a key without items is null
+	flush := func() {
+		if key != "" && len(list) > 0 {
+			doc[key] = list
+		}
+		key, list = "", nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(stripComment(scanner.Text(), true), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "---" && len(doc) == 0 && key == "":
+			continue
+		case trimmed == "...":
+			flush()
+			return doc.decode(v)
+		}
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if key == "" || line[0] != ' ' && line[0] != '-' {
+				return fmt.Errorf("line %d: list item outside of a list", number)
+			}
+			item, err := parseYAMLScalar(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+			text, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("line %d: only lists of strings are supported", number)
+			}
+			list = append(list, text)
+			continue
+		}
+		flush()
+
+		if line[0] == ' ' || line[0] == '\t' {
+			return fmt.Errorf("line %d: nested mappings are not supported", number)
+		}
+		idx := strings.Index(line, ":")
+		if idx < 0 || (idx+1 < len(line) && line[idx+1] != ' ') {
+			return fmt.Errorf("line %d: expected \"key: value\"", number)
+		}
+		name := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+
+		if value == "" {
+			// a block list follows, or the value is null
+			if err := doc.set(number, name, nil); err != nil {
+				return err
+			}
+			key = name
+			continue
+		}
+
+		var parsed interface{}
+		var err error
+		if strings.HasPrefix(value, "[") {
+			parsed, err = parseList(value, parseYAMLScalar)
+		} else {
+			parsed, err = parseYAMLScalar(value)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", number, err)
+		}
+		if err := doc.set(number, name, parsed); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	flush()
+	return doc.decode(v)
+}
+
+// parseYAMLScalar parses a string, an integer, a boolean or null
+func parseYAMLScalar(value string) (interface{}, error) {
+	if text, quoted, err := unquote(value, "''"); quoted {
+		return text, err
+	}
+
+	switch value {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if number, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return number, nil
+	}
+	if strings.ContainsAny(value[:1], "[]{}&*!|>%@`") {
+		return nil, fmt.Errorf("unsupported value %s", value)
+	}
+	return value, nil
+}
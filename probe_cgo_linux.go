@@ -0,0 +1,42 @@
+//go:build linux && cgo
+
+package gocapng
+
+// #define _GNU_SOURCE
+// #include <dlfcn.h>
+// #include <cap-ng.h>
+// #cgo LDFLAGS: -lcap-ng -ldl
+//
+// static const char *capng_library(void) {
+//	Dl_info info;
+//	if (dladdr((void *)capng_apply, &info) == 0) {
+//		return NULL;
+//	}
+//	return info.dli_fname;
+//}
+import "C"
+import (
+	"os"
+	"path/filepath"
+)
+
+// libCapNGLibrary returns the path of the libcap-ng shared object in use, or
+// "static" when it is part of the executable itself.
+func libCapNGLibrary() string {
+	name := C.capng_library()
+	if name == nil {
+		return ""
+	}
+
+	path, err := filepath.EvalSymlinks(C.GoString(name))
+	if err != nil {
+		return C.GoString(name)
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		if exe, err = filepath.EvalSymlinks(exe); err == nil && exe == path {
+			return "static"
+		}
+	}
+	return path
+}
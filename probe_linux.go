@@ -0,0 +1,159 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	prCapBSetRead     = 23
+	prCapAmbient      = 47
+	prCapAmbientIsSet = 1
+	sysPIDFDOpen      = 434
+)
+
+// KernelFeatures describes the capabilities related features of the running
+// kernel
+type KernelFeatures struct {
+	// Release is the kernel release, for example "5.15.0-91-generic", empty
+	// when /proc is not mounted
+	Release string
+	// LastCap is the highest capability the kernel knows about
+	LastCap Capability
+	// Ambient is true when the kernel supports ambient capabilities (4.3)
+	Ambient bool
+	// V3FileCaps is true when the kernel supports namespaced (version 3) file
+	// capabilities (4.14). Without it GetRootID always returns UnsetRootID.
+	// It is derived from Release, ProbeV3FileCaps checks it for real.
+	V3FileCaps bool
+	// PIDFD is true when the kernel supports pidfd_open(2) (5.3)
+	PIDFD bool
+	// Securebits is true when PR_GET_SECUREBITS is available
+	Securebits bool
+	// LibCapNG is the libcap-ng library the process uses, as reported by the
+	// dynamic loader (libcap-ng does not expose its version at runtime). It is
+	// empty when built without cgo, and "static" when libcap-ng is linked
+	// statically.
+	LibCapNG string
+}
+
+// Probe detects the capabilities related features of the running kernel,
+// without changing anything
+func Probe() (KernelFeatures, error) {
+	var features KernelFeatures
+
+	if release, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		features.Release = strings.TrimSpace(string(release))
+		features.V3FileCaps = kernelAtLeast(features.Release, 4, 14)
+	}
+
+	lastCap, err := probeLastCap()
+	if err != nil {
+		return features, err
+	}
+	features.LastCap = lastCap
+
+	_, _, errno := syscall.RawSyscall6(
+		syscall.SYS_PRCTL, prCapAmbient, prCapAmbientIsSet, 0, 0, 0, 0,
+	)
+	features.Ambient = errno == 0
+
+	_, _, errno = syscall.RawSyscall(syscall.SYS_PRCTL, prGetSecurebits, 0, 0)
+	features.Securebits = errno == 0
+
+	fd, _, errno := syscall.RawSyscall(sysPIDFDOpen, uintptr(os.Getpid()), 0, 0)
+	if errno == 0 {
+		syscall.Close(int(fd))
+		features.PIDFD = true
+	}
+
+	features.LibCapNG = libCapNGLibrary()
+
+	return features, nil
+}
+
+// probeLastCap reads /proc/sys/kernel/cap_last_cap, and when /proc is not
+// available, asks the kernel for bounding set capabilities until it does not
+// know them anymore.
+func probeLastCap() (Capability, error) {
+	content, err := os.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err == nil {
+		value, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return 0, err
+		}
+		return Capability(value), nil
+	}
+
+	capability := Capability(0)
+	for ; capability < 64; capability++ {
+		_, _, errno := syscall.RawSyscall(
+			syscall.SYS_PRCTL, prCapBSetRead, uintptr(capability), 0,
+		)
+		if errno != 0 {
+			break
+		}
+	}
+	if capability == 0 {
+		return 0, err
+	}
+	return capability - 1, nil
+}
+
+// ProbeV3FileCaps tells whether the kernel supports namespaced (version 3)
+// file capabilities, unlike KernelFeatures.V3FileCaps, by trying them: it
+// creates a temporary file in dir, the default directory for temporary files
+// when empty, writes a version 3 attribute with the root id of the user
+// namespace root on it, reads it back and removes the file. Kernels
+// supporting version 3 store the root id as seen from the file system, and
+// return the capabilities of the namespace root as version 2, while older
+// kernels return the attribute as written.
+//
+// It needs CAP_SETFCAP, and a file system supporting security attributes.
+func ProbeV3FileCaps(dir string) (bool, error) {
+	f, err := os.CreateTemp(dir, ".gocapng-probe-")
+	if err != nil {
+		return false, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	v3 := MarshalFileCaps(FileCaps{Version: vfsCapRevision3, RootID: 0})
+	if err := syscall.Setxattr(name, fileCapsXattr, v3, 0); err != nil {
+		return false, err
+	}
+
+	caps, err := ReadFileCaps(name)
+	if err != nil {
+		return false, err
+	}
+	return caps.Version == vfsCapRevision2, nil
+}
+
+// kernelAtLeast returns true if release is major.minor or newer
+func kernelAtLeast(release string, major, minor int) bool {
+	parts := strings.SplitN(release, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+
+	releaseMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if digits >= 0 {
+		parts[1] = parts[1][:digits]
+	}
+	releaseMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return releaseMajor > major || (releaseMajor == major && releaseMinor >= minor)
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestKernelAtLeast(t *testing.T) {
+	toCheck := []struct {
+		release  string
+		expected bool
+	}{
+		{release: "4.14.0", expected: true},
+		{release: "4.13.16-arch1", expected: false},
+		{release: "5.4.0-91-generic", expected: true},
+		{release: "4.19rc1", expected: true},
+		{release: "3.10.0-1160.el7.x86_64", expected: false},
+		{release: "garbage", expected: false},
+	}
+
+	for _, check := range toCheck {
+		if kernelAtLeast(check.release, 4, 14) != check.expected {
+			t.Errorf("'%s' expected %t", check.release, check.expected)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	features, err := Probe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if features.LastCap < CAPSetFCap {
+		t.Errorf("Expected last cap to be at least %d, got %d", CAPSetFCap, features.LastCap)
+	}
+
+	if features.Release == "" {
+		t.Error("Expected kernel release")
+	}
+}
+
+func TestProbeV3FileCaps(t *testing.T) {
+	state, err := ReadProcessState(0)
+	if err != nil || !state.Caps.Effective.Has(CAPSetFCap) {
+		t.Skip("Missing capabilities: setfcap")
+	}
+
+	supported, err := ProbeV3FileCaps(t.TempDir())
+	if errors.Is(err, syscall.ENOTSUP) {
+		t.Skip("The file system does not support file capabilities")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	release, err := Probe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if supported != kernelAtLeast(release.Release, 4, 14) {
+		t.Errorf("Expected v3 file capabilities support to be %t on %s", !supported, release.Release)
+	}
+}
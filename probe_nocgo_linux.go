@@ -0,0 +1,8 @@
+//go:build linux && !cgo
+
+package gocapng
+
+// libCapNGLibrary returns an empty string, libcap-ng is only used with cgo
+func libCapNGLibrary() string {
+	return ""
+}
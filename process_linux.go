@@ -0,0 +1,165 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	prGetSecurebits = 27
+	prSetSecurebits = 28
+	prSetNoNewPrivs = 38
+)
+
+// ProcessState is a snapshot of the capabilities and credentials of a process
+// as the kernel reports them, without going through libcap-ng.
+type ProcessState struct {
+	PID int
+	// PPID is the parent process id, 0 when the parent is outside of the pid
+	// namespace
+	PPID int
+	Caps Capabilities
+	UID  IDs
+	GID  IDs
+
+	// Securebits can only be read for the calling process, and holds 0 for
+	// other processes.
+	Securebits Securebits
+	NoNewPrivs bool
+}
+
+// ReadProcessState reads the state of pid from /proc/<pid>/status.
+// A pid of 0 reads the state of the calling process, including its securebits.
+func ReadProcessState(pid int) (ProcessState, error) {
+	self := pid == 0 || pid == os.Getpid()
+	if pid == 0 {
+		pid = os.Getpid()
+	}
+
+	return readProcessState(fmt.Sprintf("/proc/%d/status", pid), pid, self)
+}
+
+// ReadProcessStateAt reads the state of pid from <root>/<pid>/status, where
+// root is a directory laid out like /proc, such as the /proc of a container
+// or a test fixture. Securebits are not read.
+func ReadProcessStateAt(root string, pid int) (ProcessState, error) {
+	return readProcessState(filepath.Join(root, strconv.Itoa(pid), "status"), pid, false)
+}
+
+// readThreadState reads the state of the calling thread, which differs from
+// the state of the process after libcap-ng changed the thread only. The caller
+// must lock the goroutine to its thread.
+func readThreadState() (ProcessState, error) {
+	path := fmt.Sprintf("/proc/self/task/%d/status", syscall.Gettid())
+	return readProcessState(path, os.Getpid(), true)
+}
+
+func readProcessState(path string, pid int, self bool) (ProcessState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ProcessState{}, err
+	}
+	defer f.Close()
+
+	state, err := parseProcessStatus(f)
+	if err != nil {
+		return ProcessState{}, err
+	}
+	state.PID = pid
+
+	if self {
+		bits, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prGetSecurebits, 0, 0)
+		if errno != 0 {
+			return ProcessState{}, errno
+		}
+		state.Securebits = Securebits(bits)
+	}
+
+	return state, nil
+}
+
+// parseProcessStatus parses the content of /proc/<pid>/status
+func parseProcessStatus(r io.Reader) (ProcessState, error) {
+	var state ProcessState
+	found := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.IndexByte(line, ':')
+		if idx < 0 {
+			continue
+		}
+		key, value := line[:idx], strings.TrimSpace(line[idx+1:])
+
+		var err error
+		switch key {
+		case "CapInh":
+			state.Caps.Inheritable, err = parseCapSetHex(value)
+		case "CapPrm":
+			state.Caps.Permitted, err = parseCapSetHex(value)
+		case "CapEff":
+			state.Caps.Effective, err = parseCapSetHex(value)
+		case "CapBnd":
+			state.Caps.Bounding, err = parseCapSetHex(value)
+		case "CapAmb":
+			state.Caps.Ambient, err = parseCapSetHex(value)
+		case "Uid":
+			state.UID, err = parseIDs(value)
+		case "Gid":
+			state.GID, err = parseIDs(value)
+		case "PPid":
+			state.PPID, err = strconv.Atoi(value)
+		case "NoNewPrivs":
+			state.NoNewPrivs = value == "1"
+		default:
+			continue
+		}
+		if err != nil {
+			return ProcessState{}, fmt.Errorf("%w: %s: %s", ErrInvalidProcessStatus, key, err)
+		}
+		found++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return ProcessState{}, err
+	}
+
+	// CapAmb and NoNewPrivs are missing on older kernels
+	if found < 7 {
+		return ProcessState{}, ErrInvalidProcessStatus
+	}
+
+	return state, nil
+}
+
+func parseCapSetHex(value string) (CapSet, error) {
+	result, err := strconv.ParseUint(value, 16, 64)
+	return CapSet(result), err
+}
+
+func parseIDs(value string) (IDs, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return IDs{}, fmt.Errorf("expected 4 ids, found %d", len(fields))
+	}
+
+	var ids [4]int
+	for i, field := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return IDs{}, err
+		}
+		ids[i] = id
+	}
+
+	return IDs{Real: ids[0], Effective: ids[1], Saved: ids[2], FS: ids[3]}, nil
+}
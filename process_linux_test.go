@@ -0,0 +1,70 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseProcessStatus(t *testing.T) {
+	status := `Name:	cat
+Umask:	0022
+State:	R (running)
+PPid:	42
+Uid:	1000	1001	1002	1003
+Gid:	100	101	102	103
+CapInh:	0000000000000000
+CapPrm:	0000000000002000
+CapEff:	0000000000002000
+CapBnd:	000001ffffffffff
+CapAmb:	0000000000000000
+NoNewPrivs:	1
+`
+	state, err := parseProcessStatus(strings.NewReader(status))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if state.Caps.Permitted != NewCapSet(CAPNetRaw) || state.Caps.Effective != NewCapSet(CAPNetRaw) {
+		t.Errorf("Expected net_raw, got %s and %s", state.Caps.Permitted, state.Caps.Effective)
+	}
+
+	if state.Caps.Bounding != 0x1ffffffffff {
+		t.Errorf("Unexpected bounding set %x", uint64(state.Caps.Bounding))
+	}
+
+	expected := IDs{Real: 1000, Effective: 1001, Saved: 1002, FS: 1003}
+	if state.UID != expected {
+		t.Errorf("Expected uid %s, got %s", expected, state.UID)
+	}
+
+	if state.PPID != 42 {
+		t.Errorf("Expected ppid 42, got %d", state.PPID)
+	}
+
+	if !state.NoNewPrivs {
+		t.Error("Expected NoNewPrivs to be true")
+	}
+
+	_, err = parseProcessStatus(strings.NewReader("Name:\tcat\n"))
+	if err == nil {
+		t.Error("Expected error on missing fields")
+	}
+}
+
+func TestReadProcessStateSelf(t *testing.T) {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if state.PID != os.Getpid() {
+		t.Errorf("Expected pid %d, got %d", os.Getpid(), state.PID)
+	}
+
+	if state.UID.Real != os.Getuid() {
+		t.Errorf("Expected uid %d, got %d", os.Getuid(), state.UID.Real)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux && cgo
+
+package gocapng
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRatchetCapNG(t *testing.T) {
+	cp := Init()
+	cp.Fill(SelectCaps)
+	defer cp.Clear(SelectAll)
+
+	engageTestRatchet(t, Capabilities{Permitted: NewCapSet(CAPNetRaw)})
+
+	if err := cp.Apply(SelectCaps); !errors.Is(err, ErrRatchetEngaged) {
+		t.Errorf("Expected %s, got %v", ErrRatchetEngaged, err)
+	}
+
+	cp.Clear(SelectAll)
+	cp.Fill(SelectCaps)
+	if !cp.HaveCapability(TypePermitted, CAPNetRaw) || cp.HaveCapability(TypePermitted, CAPSysAdmin) {
+		t.Errorf("Expected Fill to stop at the ratchet, got %s", cp.pending().Permitted)
+	}
+	if cp.HaveCapability(TypeEffective, CAPNetRaw) {
+		t.Error("Expected Fill to leave the effective set empty")
+	}
+
+	if cp.Update(ActAdd, TypeEffective, CAPNetRaw) {
+		t.Error("Expected Update to refuse adding net_raw to the effective set")
+	}
+	if cp.Updatev(ActAdd, TypePermitted, CAPNetRaw, CAPSysAdmin) {
+		t.Error("Expected Updatev to refuse adding sys_admin")
+	}
+	if !cp.Update(ActDrop, TypePermitted, CAPNetRaw) {
+		t.Error("Expected drops to be allowed")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ratchet holds the capabilities the process may not grow beyond once
+// engaged
+var ratchet struct {
+	sync.Mutex
+	engaged bool
+	caps    Capabilities
+}
+
+// EngageRatchet engages the one way ratchet mode: from now on, the
+// capabilities of the process can only be dropped. It cannot be disengaged.
+//
+// While engaged:
+//
+//   - Update and Updatev refuse (return false) to add a capability the process
+//     did not hold when the ratchet was engaged or last tightened.
+//   - Fill only fills up to those capabilities.
+//   - Apply, ChangeID, EnforcePolicyCallingThread and EnforcePolicyAllThreads
+//     return ErrRatchetEngaged when they would grow any set.
+//
+// Every refusal is logged (see SetLogger). The ratchet tightens after every
+// successful Apply, ChangeID and policy enforcement.
+//
+// Engaging it again tightens the ratchet to the current state.
+//
+// The state is read from the calling thread, which libcap-ng changes, rather
+// than from the main thread of the process.
+func EngageRatchet() error {
+	runtime.LockOSThread()
+	state, err := readThreadState()
+	runtime.UnlockOSThread()
+	if err != nil {
+		return err
+	}
+
+	ratchet.Lock()
+	defer ratchet.Unlock()
+	if ratchet.engaged {
+		ratchet.caps = intersectCapabilities(ratchet.caps, state.Caps)
+	} else {
+		ratchet.engaged, ratchet.caps = true, state.Caps
+	}
+	return nil
+}
+
+// RatchetEngaged returns true once EngageRatchet was called
+func RatchetEngaged() bool {
+	ratchet.Lock()
+	defer ratchet.Unlock()
+	return ratchet.engaged
+}
+
+// ratchetAllows returns nil if caps do not grow any of the sets or'ed into t
+// beyond the ratchet, and otherwise logs and returns ErrRatchetEngaged
+func ratchetAllows(operation string, t Type, caps Capabilities) error {
+	ratchet.Lock()
+	defer ratchet.Unlock()
+	if !ratchet.engaged {
+		return nil
+	}
+
+	growth := &CapabilityCheckError{Err: ErrRatchetEngaged, Sets: map[Type]CapSet{}}
+	for _, set := range allTypes {
+		if t&set == 0 {
+			continue
+		}
+		if extra := caps.Get(set).Difference(ratchet.caps.Get(set)); !extra.IsEmpty() {
+			growth.Sets[set] = extra
+		}
+	}
+	if len(growth.Sets) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("%s: %w", operation, growth)
+	logf("%s", err)
+	return err
+}
+
+// ratchetRefusesAdd returns true, and logs, if adding the capabilities to the
+// sets or'ed into t would grow them beyond the ratchet
+func ratchetRefusesAdd(t Type, capabilities ...Capability) bool {
+	var caps Capabilities
+	for _, set := range allTypes {
+		if t&set != 0 {
+			caps.Set(set, NewCapSet(capabilities...))
+		}
+	}
+	return ratchetAllows(fmt.Sprintf("Update(add, %s)", NewCapSet(capabilities...)), t, caps) != nil
+}
+
+// tightenRatchet lowers the ratchet to caps for the sets or'ed into t
+func tightenRatchet(t Type, caps Capabilities) {
+	ratchet.Lock()
+	defer ratchet.Unlock()
+	if !ratchet.engaged {
+		return
+	}
+	for _, set := range allTypes {
+		if t&set != 0 {
+			ratchet.caps.Set(set, ratchet.caps.Get(set).Intersect(caps.Get(set)))
+		}
+	}
+}
+
+// ratchetLimit returns the capabilities allowed by the ratchet, and false when
+// it is not engaged
+func ratchetLimit() (Capabilities, bool) {
+	ratchet.Lock()
+	defer ratchet.Unlock()
+	return ratchet.caps, ratchet.engaged
+}
+
+func intersectCapabilities(a, b Capabilities) Capabilities {
+	for _, t := range allTypes {
+		a.Set(t, a.Get(t).Intersect(b.Get(t)))
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+// engageTestRatchet engages the ratchet at caps, and disengages it, which
+// EngageRatchet cannot, when the test ends
+func engageTestRatchet(t *testing.T, caps Capabilities) *bytes.Buffer {
+	var logs bytes.Buffer
+	loggerLock.Lock()
+	previous := logger
+	loggerLock.Unlock()
+	SetLogger(log.New(&logs, "", 0))
+
+	ratchet.Lock()
+	ratchet.engaged, ratchet.caps = true, caps
+	ratchet.Unlock()
+
+	t.Cleanup(func() {
+		ratchet.Lock()
+		ratchet.engaged, ratchet.caps = false, Capabilities{}
+		ratchet.Unlock()
+		SetLogger(previous)
+	})
+	return &logs
+}
+
+func TestRatchet(t *testing.T) {
+	if err := ratchetAllows("Apply", TypeEffective, Capabilities{Effective: FullCapSet()}); err != nil {
+		t.Fatalf("Expected a disengaged ratchet to allow everything, got %s", err)
+	}
+
+	logs := engageTestRatchet(t, Capabilities{
+		Effective: NewCapSet(CAPNetRaw),
+		Permitted: NewCapSet(CAPNetRaw, CAPNetBindService),
+	})
+
+	if ratchetRefusesAdd(TypePermitted, CAPNetBindService) {
+		t.Error("Expected adding a held capability to be allowed")
+	}
+	if !ratchetRefusesAdd(TypeEffective|TypePermitted, CAPNetBindService) {
+		t.Error("Expected adding net_bind_service to the effective set to be refused")
+	}
+	if !strings.Contains(logs.String(), "effective: net_bind_service") {
+		t.Errorf("Expected the refusal to be logged, got '%s'", logs)
+	}
+
+	pending := Capabilities{Effective: NewCapSet(CAPNetRaw), Bounding: NewCapSet(CAPSysAdmin)}
+	if err := ratchetAllows("Apply", TypeEffective|TypeBoundingSet, pending); !errors.Is(err, ErrRatchetEngaged) {
+		t.Errorf("Expected %s, got %v", ErrRatchetEngaged, err)
+	}
+	if err := ratchetAllows("Apply", TypeEffective, pending); err != nil {
+		t.Errorf("Expected the effective set to be allowed, got %s", err)
+	}
+
+	tightenRatchet(TypeEffective|TypePermitted, Capabilities{Permitted: NewCapSet(CAPNetBindService)})
+	if !ratchetRefusesAdd(TypeEffective, CAPNetRaw) || !ratchetRefusesAdd(TypePermitted, CAPNetRaw) {
+		t.Error("Expected the ratchet to be tightened")
+	}
+	if ratchetRefusesAdd(TypePermitted, CAPNetBindService) {
+		t.Error("Expected net_bind_service to stay allowed in the permitted set")
+	}
+}
@@ -0,0 +1,124 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allTypes lists the capability sets in the order they are reported
+var allTypes = []Type{
+	TypeEffective, TypePermitted, TypeInheritable, TypeBoundingSet, TypeAmbient,
+}
+
+// CapabilityCheckError is returned by Require and Forbid. It unwraps to
+// ErrMissingCapabilities or ErrForbiddenCapabilities.
+type CapabilityCheckError struct {
+	Err error
+	// Sets holds, for every set that failed the check, the capabilities that
+	// are missing (Require) or present (Forbid)
+	Sets map[Type]CapSet
+	// Unsupported holds the required capabilities the running kernel does
+	// not know, which are always missing
+	Unsupported CapSet
+}
+
+func (e *CapabilityCheckError) Error() string {
+	var parts []string
+	for _, t := range allTypes {
+		if set, ok := e.Sets[t]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %s", t, set))
+		}
+	}
+	if !e.Unsupported.IsEmpty() {
+		parts = append(parts, fmt.Sprintf("not supported by the kernel: %s", e.Unsupported))
+	}
+	return fmt.Sprintf("%s: %s", e.Err, strings.Join(parts, "; "))
+}
+
+func (e *CapabilityCheckError) Unwrap() error {
+	return e.Err
+}
+
+// Require returns an error listing the capabilities missing from the calling
+// process. set may hold several or'ed types, in which case every one of them
+// must hold all of caps. Capabilities the running kernel does not know are
+// reported as missing.
+func Require(set Type, caps ...Capability) error {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return err
+	}
+	return require(state.Caps, set, NewCapSet(caps...), FullCapSet())
+}
+
+// require checks that current holds caps, where supported holds the
+// capabilities of the running kernel
+func require(current Capabilities, set Type, caps, supported CapSet) error {
+	err := checkCapabilities(current, set, ErrMissingCapabilities, func(have CapSet) CapSet {
+		return caps.Difference(have)
+	})
+	if checkErr, ok := err.(*CapabilityCheckError); ok {
+		checkErr.Unsupported = caps.Difference(supported)
+	}
+	return err
+}
+
+// Forbid returns an error listing the capabilities of caps the calling process
+// holds. set may hold several or'ed types, in which case none of them may hold
+// any of caps.
+func Forbid(set Type, caps ...Capability) error {
+	state, err := ReadProcessState(0)
+	if err != nil {
+		return err
+	}
+	return forbid(state.Caps, set, NewCapSet(caps...))
+}
+
+func forbid(current Capabilities, set Type, caps CapSet) error {
+	return checkCapabilities(current, set, ErrForbiddenCapabilities, func(have CapSet) CapSet {
+		return caps.Intersect(have)
+	})
+}
+
+// checkCapabilities returns a CapabilityCheckError holding the non empty
+// results of failed for every type or'ed into set
+func checkCapabilities(current Capabilities, set Type, err error, failed func(CapSet) CapSet) error {
+	result := &CapabilityCheckError{Err: err, Sets: map[Type]CapSet{}}
+	for _, t := range allTypes {
+		if set&t == 0 {
+			continue
+		}
+		if wrong := failed(current.Get(t)); !wrong.IsEmpty() {
+			result.Sets[t] = wrong
+		}
+	}
+
+	if len(result.Sets) == 0 {
+		return nil
+	}
+	return result
+}
+
+// MustRequire is like Require, but prints the missing capabilities to the
+// standard error and exits the process when some are missing
+func MustRequire(set Type, caps ...Capability) {
+	mustSucceed(Require(set, caps...))
+}
+
+// MustForbid is like Forbid, but prints the forbidden capabilities to the
+// standard error and exits the process when some are present
+func MustForbid(set Type, caps ...Capability) {
+	mustSucceed(Forbid(set, caps...))
+}
+
+func mustSucceed(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+	os.Exit(1)
+}
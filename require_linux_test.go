@@ -0,0 +1,66 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequireForbid(t *testing.T) {
+	current := Capabilities{
+		Effective: NewCapSet(CAPNetBindService),
+		Permitted: NewCapSet(CAPNetBindService, CAPNetRaw),
+		Bounding:  NewCapSet(CAPNetBindService, CAPNetRaw, CAPSysAdmin),
+	}
+
+	err := require(current, TypeEffective|TypePermitted, NewCapSet(CAPNetBindService, CAPNetRaw), FullCapSet())
+	var checkErr *CapabilityCheckError
+	if !errors.As(err, &checkErr) || !errors.Is(err, ErrMissingCapabilities) {
+		t.Fatalf("Expected %s, got %v", ErrMissingCapabilities, err)
+	}
+	if len(checkErr.Sets) != 1 || checkErr.Sets[TypeEffective] != NewCapSet(CAPNetRaw) {
+		t.Errorf("Expected only net_raw missing from effective, got %s", err)
+	}
+	if err.Error() != "missing capabilities: effective: net_raw" {
+		t.Errorf("Unexpected message '%s'", err)
+	}
+
+	if err := require(current, TypePermitted, NewCapSet(CAPNetRaw), FullCapSet()); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+
+	err = forbid(current, TypePermitted|TypeBoundingSet, NewCapSet(CAPSysAdmin))
+	if !errors.As(err, &checkErr) || !errors.Is(err, ErrForbiddenCapabilities) {
+		t.Fatalf("Expected %s, got %v", ErrForbiddenCapabilities, err)
+	}
+	if len(checkErr.Sets) != 1 || checkErr.Sets[TypeBoundingSet] != NewCapSet(CAPSysAdmin) {
+		t.Errorf("Expected sys_admin present in the bounding set, got %s", err)
+	}
+
+	if err := forbid(current, TypeEffective|TypeAmbient, NewCapSet(CAPSysAdmin)); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestRequireUnsupported(t *testing.T) {
+	current := Capabilities{
+		Effective: NewCapSet(CAPNetBindService),
+		Permitted: NewCapSet(CAPNetBindService),
+	}
+	supported := CapSet(1<<(CAPSysAdmin+1) - 1)
+
+	err := require(current, TypeEffective, NewCapSet(CAPNetBindService, CAPCheckpointRestore), supported)
+	var checkErr *CapabilityCheckError
+	if !errors.As(err, &checkErr) {
+		t.Fatalf("Expected %s, got %v", ErrMissingCapabilities, err)
+	}
+	if checkErr.Sets[TypeEffective] != NewCapSet(CAPCheckpointRestore) ||
+		checkErr.Unsupported != NewCapSet(CAPCheckpointRestore) {
+		t.Errorf("Expected checkpoint_restore missing and unsupported, got %+v", checkErr)
+	}
+	expected := "missing capabilities: effective: checkpoint_restore; not supported by the kernel: checkpoint_restore"
+	if err.Error() != expected {
+		t.Errorf("Expected '%s' but have '%s' instead", expected, err)
+	}
+}
@@ -0,0 +1,239 @@
+package gocapng
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// Operation is a privileged operation, see RequiredFor
+type Operation string
+
+// Operations with a known capability requirement
+const (
+	OpBindPrivilegedPort Operation = "bind_privileged_port"
+	OpRawSocket          Operation = "raw_socket"
+	OpPacketSocket       Operation = "packet_socket"
+	OpSetSocketMark      Operation = "set_socket_mark"
+	OpNetworkConfig      Operation = "network_config"
+	OpChownOtherUID      Operation = "chown_other_uid"
+	OpKillOtherUID       Operation = "kill_other_uid"
+	OpLockMemory         Operation = "lock_memory"
+	OpMount              Operation = "mount"
+	OpSetTime            Operation = "set_time"
+	OpPTrace             Operation = "ptrace"
+	OpSetFileCaps        Operation = "set_file_caps"
+	OpChangeUID          Operation = "change_uid"
+	OpChangeGID          Operation = "change_gid"
+	OpSetGroups          Operation = "set_groups"
+	OpDropBoundingSet    Operation = "drop_bounding_set"
+	OpSetSecurebits      Operation = "set_securebits"
+	OpChroot             Operation = "chroot"
+	OpLoadModule         Operation = "load_module"
+	OpReboot             Operation = "reboot"
+	OpRaisePriority      Operation = "raise_priority"
+	OpReadKernelLog      Operation = "read_kernel_log"
+	OpLoadBPF            Operation = "load_bpf"
+)
+
+// Requirement is what the kernel checks before allowing an Operation
+type Requirement struct {
+	Operation Operation
+	// AnyOf holds the capabilities that allow the operation, any of them is
+	// enough
+	AnyOf []Capability
+	// Condition describes when the capability is needed
+	Condition string
+}
+
+// Satisfied returns true if set holds one of the required capabilities
+func (r Requirement) Satisfied(set CapSet) bool {
+	for _, capability := range r.AnyOf {
+		if set.Has(capability) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	names := make([]string, 0, len(r.AnyOf))
+	for _, capability := range r.AnyOf {
+		names = append(names, capability.String())
+	}
+	return fmt.Sprintf("%s requires %s (%s)", r.Operation, strings.Join(names, " or "), r.Condition)
+}
+
+var requirements = []Requirement{
+	{
+		Operation: OpBindPrivilegedPort,
+		AnyOf:     []Capability{CAPNetBindService},
+		Condition: "binding a port below net.ipv4.ip_unprivileged_port_start, 1024 by default",
+	},
+	{
+		Operation: OpRawSocket,
+		AnyOf:     []Capability{CAPNetRaw},
+		Condition: "opening a SOCK_RAW socket",
+	},
+	{
+		Operation: OpPacketSocket,
+		AnyOf:     []Capability{CAPNetRaw},
+		Condition: "opening an AF_PACKET socket",
+	},
+	{
+		Operation: OpSetSocketMark,
+		AnyOf:     []Capability{CAPNetAdmin, CAPNetRaw},
+		Condition: "setting SO_MARK, CAPNetRaw is accepted since Linux 5.17",
+	},
+	{
+		Operation: OpNetworkConfig,
+		AnyOf:     []Capability{CAPNetAdmin},
+		Condition: "changing interfaces, routes or firewall rules",
+	},
+	{
+		Operation: OpChownOtherUID,
+		AnyOf:     []Capability{CAPCHOWN},
+		Condition: "changing the owner of a file, or its group to a group the process is not member of",
+	},
+	{
+		Operation: OpKillOtherUID,
+		AnyOf:     []Capability{CAPKill},
+		Condition: "sending a signal to a process whose real and saved uid differ from the real and effective uid of the sender",
+	},
+	{
+		Operation: OpLockMemory,
+		AnyOf:     []Capability{CAPIPCLock},
+		Condition: "locking more memory than RLIMIT_MEMLOCK",
+	},
+	{
+		Operation: OpMount,
+		AnyOf:     []Capability{CAPSysAdmin},
+		Condition: "mounting or unmounting a file system",
+	},
+	{
+		Operation: OpSetTime,
+		AnyOf:     []Capability{CAPSysTime},
+		Condition: "setting the system clock",
+	},
+	{
+		Operation: OpPTrace,
+		AnyOf:     []Capability{CAPSysPTrace},
+		Condition: "tracing a process of another uid, or with capabilities the tracer does not have",
+	},
+	{
+		Operation: OpSetFileCaps,
+		AnyOf:     []Capability{CAPSetFCap},
+		Condition: "writing the security.capability extended attribute",
+	},
+	{
+		Operation: OpChangeUID,
+		AnyOf:     []Capability{CAPSetUID},
+		Condition: "changing to a uid that is not the current real, effective or saved uid",
+	},
+	{
+		Operation: OpChangeGID,
+		AnyOf:     []Capability{CAPSetGID},
+		Condition: "changing to a gid that is not the current real, effective or saved gid",
+	},
+	{
+		Operation: OpSetGroups,
+		AnyOf:     []Capability{CAPSetGID},
+		Condition: "changing the supplementary groups",
+	},
+	{
+		Operation: OpDropBoundingSet,
+		AnyOf:     []Capability{CAPSetPCap},
+		Condition: "dropping a capability from the bounding set",
+	},
+	{
+		Operation: OpSetSecurebits,
+		AnyOf:     []Capability{CAPSetPCap},
+		Condition: "changing the securebits",
+	},
+	{
+		Operation: OpChroot,
+		AnyOf:     []Capability{CAPSysChRoot},
+		Condition: "calling chroot",
+	},
+	{
+		Operation: OpLoadModule,
+		AnyOf:     []Capability{CAPSysModule},
+		Condition: "loading or unloading a kernel module",
+	},
+	{
+		Operation: OpReboot,
+		AnyOf:     []Capability{CAPSysBoot},
+		Condition: "calling reboot or kexec_load",
+	},
+	{
+		Operation: OpRaisePriority,
+		AnyOf:     []Capability{CAPSysNice},
+		Condition: "lowering the nice value below RLIMIT_NICE, or setting a real time policy",
+	},
+	{
+		Operation: OpReadKernelLog,
+		AnyOf:     []Capability{CAPSYSLOG},
+		Condition: "reading the kernel log when kernel.dmesg_restrict is set",
+	},
+	{
+		Operation: OpLoadBPF,
+		AnyOf:     []Capability{CAPBPF, CAPSysAdmin},
+		Condition: "loading a BPF program when kernel.unprivileged_bpf_disabled is set, CAPBPF exists since Linux 5.8",
+	},
+}
+
+// Operations returns every operation RequiredFor knows about
+func Operations() []Operation {
+	operations := make([]Operation, 0, len(requirements))
+	for _, requirement := range requirements {
+		operations = append(operations, requirement.Operation)
+	}
+	return operations
+}
+
+// RequiredFor returns the capabilities needed for op, or
+// ErrUnknownOperation.
+func RequiredFor(op Operation) (Requirement, error) {
+	for _, requirement := range requirements {
+		if requirement.Operation == op {
+			requirement.AnyOf = append([]Capability(nil), requirement.AnyOf...)
+			return requirement, nil
+		}
+	}
+	return Requirement{}, ErrUnknownOperation
+}
+
+// RequirementError is returned when an operation failed, or is expected to
+// fail, for lack of a capability. Err is the error the operation returns.
+type RequirementError struct {
+	Requirement Requirement
+	Err         error
+}
+
+func (e *RequirementError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.Requirement)
+}
+
+func (e *RequirementError) Unwrap() error {
+	return e.Err
+}
+
+// requirementError wraps err with the requirement of op
+func requirementError(op Operation, err error) error {
+	requirement, lookupErr := RequiredFor(op)
+	if lookupErr != nil {
+		return err
+	}
+	return &RequirementError{Requirement: requirement, Err: err}
+}
+
+// permissionError wraps err with the requirement of op when errno, the error
+// of the failed system call, reports a missing privilege, and returns err
+// unchanged otherwise
+func permissionError(op Operation, err, errno error) error {
+	if !errors.Is(errno, syscall.EPERM) {
+		return err
+	}
+	return requirementError(op, err)
+}
@@ -0,0 +1,80 @@
+package gocapng
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestRequiredFor(t *testing.T) {
+	toCheck := []struct {
+		op       Operation
+		expected []Capability
+	}{
+		{
+			op:       OpBindPrivilegedPort,
+			expected: []Capability{CAPNetBindService},
+		},
+		{
+			op:       OpSetSocketMark,
+			expected: []Capability{CAPNetAdmin, CAPNetRaw},
+		},
+		{
+			op:       OpSetFileCaps,
+			expected: []Capability{CAPSetFCap},
+		},
+	}
+
+	for _, check := range toCheck {
+		requirement, err := RequiredFor(check.op)
+		if err != nil {
+			t.Errorf("'%s' expected to be known but have '%s' instead", check.op, err)
+			continue
+		}
+		if NewCapSet(requirement.AnyOf...) != NewCapSet(check.expected...) {
+			t.Errorf(
+				"'%s' expected to require %v but have %v instead",
+				check.op, check.expected, requirement.AnyOf,
+			)
+		}
+		if !requirement.Satisfied(NewCapSet(check.expected[0])) || requirement.Satisfied(0) {
+			t.Errorf("'%s' has an unexpected Satisfied", check.op)
+		}
+	}
+
+	if _, err := RequiredFor(Operation("fly")); !errors.Is(err, ErrUnknownOperation) {
+		t.Errorf("Expected %s, got %v", ErrUnknownOperation, err)
+	}
+
+	for _, op := range Operations() {
+		if _, err := RequiredFor(op); err != nil {
+			t.Errorf("'%s' expected to be known but have '%s' instead", op, err)
+		}
+	}
+}
+
+func TestRequirementError(t *testing.T) {
+	err := requirementError(OpChangeUID, ErrChangingUIDFailed)
+
+	var requirementErr *RequirementError
+	if !errors.As(err, &requirementErr) || requirementErr.Requirement.Operation != OpChangeUID {
+		t.Fatalf("Expected a RequirementError for %s, got %v", OpChangeUID, err)
+	}
+	if !errors.Is(err, ErrChangingUIDFailed) {
+		t.Errorf("Expected %v to wrap %s", err, ErrChangingUIDFailed)
+	}
+}
+
+func TestPermissionError(t *testing.T) {
+	err := permissionError(OpChangeUID, ErrChangingUIDFailed, syscall.EPERM)
+	var requirementErr *RequirementError
+	if !errors.As(err, &requirementErr) || !errors.Is(err, ErrChangingUIDFailed) {
+		t.Errorf("Expected a RequirementError wrapping %s, got %v", ErrChangingUIDFailed, err)
+	}
+
+	for _, errno := range []error{nil, syscall.EAGAIN, syscall.ENOMEM} {
+		if err := permissionError(OpChangeUID, ErrChangingUIDFailed, errno); err != ErrChangingUIDFailed {
+			t.Errorf("'%v' expected %s but have %v instead", errno, ErrChangingUIDFailed, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+//go:build linux
+
+package gocapng
+
+// ExecOptions describes the process calling execve and the file being
+// executed, apart from the file capabilities.
+type ExecOptions struct {
+	// UID and GID are the credentials of the process calling execve
+	UID IDs
+	GID IDs
+
+	// FileUID and FileGID are the owner of the executable, SetUID and SetGID
+	// its S_ISUID and S_ISGID mode bits.
+	FileUID int
+	FileGID int
+	SetUID  bool
+	SetGID  bool
+
+	Securebits Securebits
+	NoNewPrivs bool
+
+	// NoSUID is true when the executable is on a filesystem mounted with
+	// nosuid, where the kernel ignores both the set-id bits and the file
+	// capabilities.
+	NoSUID bool
+
+	// NamespaceRootID is the uid (as seen from the file system) that is root
+	// in the user namespace of the process, 0 for the initial namespace.
+	// Version 3 file capabilities are only honoured when their root id
+	// matches it.
+	NamespaceRootID int
+}
+
+// ExecResult is the state of a process after execve
+type ExecResult struct {
+	Caps       Capabilities
+	UID        IDs
+	GID        IDs
+	Securebits Securebits
+
+	// SetID is true when the execution changed the effective uid or gid
+	SetID bool
+	// Gained holds the permitted capabilities the parent did not have
+	Gained CapSet
+}
+
+// SimulateExec returns the state of a process after it calls execve, without
+// running anything.
+//
+// SimulateExec implements the rules the kernel applies on execve:
+//
+//	P'(ambient)     = (file is privileged) ? 0 : P(ambient)
+//	P'(permitted)   = (P(inheritable) & F(inheritable)) |
+//	                  (F(permitted) & P(bounding)) | P'(ambient)
+//	P'(effective)   = F(effective) ? P'(permitted) : P'(ambient)
+//	P'(inheritable) = P(inheritable)
+//	P'(bounding)    = P(bounding)
+//
+// including the special handling of root (unless SecureNoRoot is set), the
+// set-id bits, and no_new_privs that keeps the process from gaining
+// privileges. SecureKeepCaps is always cleared.
+//
+// When the file effective bit is set and not all the file permitted
+// capabilities can be granted, the kernel refuses to run the file, and
+// SimulateExec returns ErrExecFileCapabilitiesNotGranted.
+func SimulateExec(parent Capabilities, file FileCaps, opts ExecOptions) (ExecResult, error) {
+	uid, gid := opts.UID, opts.GID
+	suid := !opts.NoSUID && !opts.NoNewPrivs
+	if suid && opts.SetUID {
+		uid.Effective = opts.FileUID
+	}
+	if suid && opts.SetGID {
+		gid.Effective = opts.FileGID
+	}
+
+	child := Capabilities{
+		Inheritable: parent.Inheritable,
+		Bounding:    parent.Bounding,
+		Ambient:     parent.Ambient,
+	}
+
+	effective, hasFileCaps := false, false
+	if file.Version != 0 && !opts.NoSUID &&
+		(file.Version < 3 || file.RootID == UnsetRootID || file.RootID == opts.NamespaceRootID) {
+		hasFileCaps = true
+		effective = file.Effective
+		child.Permitted = parent.Bounding.Intersect(file.Permitted).
+			Union(parent.Inheritable.Intersect(file.Inheritable))
+		if effective && !file.Permitted.IsSubset(child.Permitted) {
+			return ExecResult{}, ErrExecFileCapabilitiesNotGranted
+		}
+	}
+
+	// a setuid root file with file capabilities only gets its file
+	// capabilities when executed by a non root user
+	setUIDRootWithFileCaps := hasFileCaps && uid.Real != 0 && uid.Effective == 0
+	if opts.Securebits&SecureNoRoot == 0 && !setUIDRootWithFileCaps {
+		if uid.Effective == 0 || uid.Real == 0 {
+			child.Permitted = parent.Bounding.Union(parent.Inheritable)
+		}
+		if uid.Effective == 0 {
+			effective = true
+		}
+	}
+
+	setID := uid.Effective != opts.UID.Real || gid.Effective != opts.GID.Real
+	if opts.NoNewPrivs && (setID || !child.Permitted.IsSubset(parent.Permitted)) {
+		uid.Effective, gid.Effective = uid.Real, gid.Real
+		child.Permitted = child.Permitted.Intersect(parent.Permitted)
+	}
+
+	uid.Saved, uid.FS = uid.Effective, uid.Effective
+	gid.Saved, gid.FS = gid.Effective, gid.Effective
+
+	if hasFileCaps || setID {
+		child.Ambient = 0
+	}
+	child.Permitted = child.Permitted.Union(child.Ambient)
+	if effective {
+		child.Effective = child.Permitted
+	} else {
+		child.Effective = child.Ambient
+	}
+
+	return ExecResult{
+		Caps:       child,
+		UID:        uid,
+		GID:        gid,
+		Securebits: opts.Securebits &^ SecureKeepCaps,
+		SetID:      setID,
+		Gained:     child.Permitted.Difference(parent.Permitted),
+	}, nil
+}
+
+// fsCapabilities are the capabilities the kernel follows on fsuid changes
+var fsCapabilities = NewCapSet(
+	CAPCHOWN, CAPDACOverride, CAPDACReadSearch, CAPFOwner, CAPFSetID,
+	CAPLinuxImmutable, CAPMkNod, CAPMACOverride,
+)
+
+// SimulateSetUID returns the capabilities of a process after its uids change
+// from old to next, without changing anything.
+//
+// When the real, effective or saved uid change (setuid, setreuid,
+// setresuid), SimulateSetUID implements the kernel fixups:
+//
+//   - when at least one of the uids was 0 and none of them is 0 anymore, the
+//     permitted and effective sets are cleared, unless SecureKeepCaps is set,
+//     and the ambient set is always cleared.
+//   - when the effective uid changes from 0 to non 0, the effective set is
+//     cleared.
+//   - when the effective uid changes from non 0 to 0, the permitted set is
+//     copied into the effective set.
+//
+// When only the filesystem uid changes (setfsuid), the file system related
+// capabilities (CAPCHOWN, CAPDACOverride, CAPDACReadSearch, CAPFOwner,
+// CAPFSetID, CAPLinuxImmutable, CAPMkNod and CAPMACOverride) are dropped from
+// the effective set when it moves away from 0, and raised from the permitted
+// set when it moves to 0.
+//
+// None of the above happens when SecureNoSetUIDFixup is set.
+//
+// Like the kernel, changing to uids that are not the current real, effective
+// or saved uid (or filesystem uid for setfsuid) requires CAPSetUID in the
+// effective set, otherwise ErrChangingUIDFailed is returned, wrapped in a
+// RequirementError.
+func SimulateSetUID(caps Capabilities, bits Securebits, old, next IDs) (Capabilities, error) {
+	if next.Real != old.Real || next.Effective != old.Effective || next.Saved != old.Saved {
+		if !caps.Effective.Has(CAPSetUID) &&
+			(!idIn(next.Real, old) || !idIn(next.Effective, old) || !idIn(next.Saved, old)) {
+			return caps, requirementError(OpChangeUID, ErrChangingUIDFailed)
+		}
+		if bits&SecureNoSetUIDFixup == 0 {
+			caps = setuidFixup(caps, bits, old, next)
+		}
+		return caps, nil
+	}
+
+	if next.FS == old.FS {
+		return caps, nil
+	}
+
+	if !caps.Effective.Has(CAPSetUID) && !idIn(next.FS, old) {
+		return caps, requirementError(OpChangeUID, ErrChangingUIDFailed)
+	}
+	if bits&SecureNoSetUIDFixup != 0 {
+		return caps, nil
+	}
+	if old.FS == 0 && next.FS != 0 {
+		caps.Effective = caps.Effective.Difference(fsCapabilities)
+	}
+	if old.FS != 0 && next.FS == 0 {
+		caps.Effective = caps.Effective.Union(fsCapabilities.Intersect(caps.Permitted))
+	}
+	return caps, nil
+}
+
+// capsetAllowed implements the checks of the capset(2) syscall
+func capsetAllowed(current, next Capabilities) bool {
+	if !current.Effective.Has(CAPSetPCap) &&
+		!next.Inheritable.IsSubset(current.Inheritable.Union(current.Permitted)) {
+		return false
+	}
+	if !next.Inheritable.IsSubset(current.Inheritable.Union(current.Bounding)) {
+		return false
+	}
+	if !next.Permitted.IsSubset(current.Permitted) {
+		return false
+	}
+	return next.Effective.IsSubset(next.Permitted)
+}
+
+// capset returns current after a successful capset(2) of next
+func capset(current, next Capabilities) Capabilities {
+	current.Effective = next.Effective
+	current.Permitted = next.Permitted
+	current.Inheritable = next.Inheritable
+	// the kernel keeps ambient a subset of permitted and inheritable
+	current.Ambient = current.Ambient.Intersect(next.Permitted.Intersect(next.Inheritable))
+	return current
+}
+
+// ambientRaiseAllowed implements the checks of PR_CAP_AMBIENT_RAISE
+func ambientRaiseAllowed(caps Capabilities, bits Securebits, capability Capability) bool {
+	if bits&SecureNoCapAmbientRaise != 0 {
+		return false
+	}
+	return caps.Permitted.Has(capability) && caps.Inheritable.Has(capability)
+}
+
+// setuidFixup implements the capabilities changes the kernel makes when the
+// real, effective and saved uids change.
+func setuidFixup(caps Capabilities, bits Securebits, old, next IDs) Capabilities {
+	if idIn(0, old) && !idIn(0, next) {
+		if bits&SecureKeepCaps == 0 {
+			caps.Permitted = 0
+			caps.Effective = 0
+		}
+		caps.Ambient = 0
+	}
+	if old.Effective == 0 && next.Effective != 0 {
+		caps.Effective = 0
+	}
+	if old.Effective != 0 && next.Effective == 0 {
+		caps.Effective = caps.Permitted
+	}
+	return caps
+}
+
+// idIn returns true if id is the real, effective or saved id
+func idIn(id int, ids IDs) bool {
+	return id == ids.Real || id == ids.Effective || id == ids.Saved
+}
@@ -0,0 +1,239 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSimulateExec(t *testing.T) {
+	all := CapSet(1<<(CAPLastCap+1) - 1)
+	user := IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+	root := IDs{}
+	netBind := NewCapSet(CAPNetBindService)
+
+	toCheck := []struct {
+		name     string
+		parent   Capabilities
+		file     FileCaps
+		opts     ExecOptions
+		expected Capabilities
+		gained   CapSet
+	}{
+		{
+			name:     "unprivileged plain file",
+			parent:   Capabilities{Bounding: all},
+			opts:     ExecOptions{UID: user, GID: user},
+			expected: Capabilities{Bounding: all},
+		},
+		{
+			name:   "file capabilities with effective bit",
+			parent: Capabilities{Bounding: all},
+			file: FileCaps{
+				Version: 2, Permitted: netBind, Effective: true, RootID: UnsetRootID,
+			},
+			opts: ExecOptions{UID: user, GID: user},
+			expected: Capabilities{
+				Effective: netBind, Permitted: netBind, Bounding: all,
+			},
+			gained: netBind,
+		},
+		{
+			name:   "ambient kept on plain file",
+			parent: Capabilities{Permitted: netBind, Inheritable: netBind, Ambient: netBind, Bounding: all},
+			opts:   ExecOptions{UID: user, GID: user},
+			expected: Capabilities{
+				Effective: netBind, Permitted: netBind, Inheritable: netBind,
+				Ambient: netBind, Bounding: all,
+			},
+		},
+		{
+			name:   "ambient cleared by file capabilities",
+			parent: Capabilities{Permitted: netBind, Inheritable: netBind, Ambient: netBind, Bounding: all},
+			file:   FileCaps{Version: 2, RootID: UnsetRootID},
+			opts:   ExecOptions{UID: user, GID: user},
+			expected: Capabilities{
+				Inheritable: netBind, Bounding: all,
+			},
+		},
+		{
+			name:   "root gets the bounding set",
+			parent: Capabilities{Effective: all, Permitted: all, Bounding: netBind},
+			opts:   ExecOptions{UID: root, GID: root},
+			expected: Capabilities{
+				Effective: netBind, Permitted: netBind, Bounding: netBind,
+			},
+		},
+		{
+			name:     "root with SecureNoRoot",
+			parent:   Capabilities{Effective: all, Permitted: all, Bounding: all},
+			opts:     ExecOptions{UID: root, GID: root, Securebits: SecureNoRoot},
+			expected: Capabilities{Bounding: all},
+		},
+		{
+			name:   "setuid root",
+			parent: Capabilities{Bounding: all},
+			opts:   ExecOptions{UID: user, GID: user, SetUID: true, FileUID: 0},
+			expected: Capabilities{
+				Effective: all, Permitted: all, Bounding: all,
+			},
+			gained: all,
+		},
+		{
+			name:     "setuid root with no_new_privs",
+			parent:   Capabilities{Bounding: all},
+			opts:     ExecOptions{UID: user, GID: user, SetUID: true, FileUID: 0, NoNewPrivs: true},
+			expected: Capabilities{Bounding: all},
+		},
+		{
+			name:   "file capabilities with no_new_privs",
+			parent: Capabilities{Bounding: all},
+			file:   FileCaps{Version: 2, Permitted: netBind, RootID: UnsetRootID},
+			opts:   ExecOptions{UID: user, GID: user, NoNewPrivs: true},
+			expected: Capabilities{
+				Bounding: all,
+			},
+		},
+		{
+			name:     "foreign namespace root id",
+			parent:   Capabilities{Bounding: all},
+			file:     FileCaps{Version: 3, Permitted: netBind, Effective: true, RootID: 100000},
+			opts:     ExecOptions{UID: user, GID: user},
+			expected: Capabilities{Bounding: all},
+		},
+	}
+
+	for _, check := range toCheck {
+		result, err := SimulateExec(check.parent, check.file, check.opts)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if result.Caps != check.expected {
+			t.Errorf("'%s' expected %+v got %+v", check.name, check.expected, result.Caps)
+		}
+		if result.Gained != check.gained {
+			t.Errorf("'%s' expected gained '%s' got '%s'", check.name, check.gained, result.Gained)
+		}
+	}
+}
+
+func TestSimulateExecMissingFileCapabilities(t *testing.T) {
+	parent := Capabilities{Bounding: NewCapSet(CAPCHOWN)}
+	file := FileCaps{
+		Version:   2,
+		Permitted: NewCapSet(CAPNetRaw),
+		Effective: true,
+		RootID:    UnsetRootID,
+	}
+	opts := ExecOptions{UID: IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}}
+
+	_, err := SimulateExec(parent, file, opts)
+	if !errors.Is(err, ErrExecFileCapabilitiesNotGranted) {
+		t.Errorf("Expected %s, got %v", ErrExecFileCapabilitiesNotGranted, err)
+	}
+}
+
+func TestSimulateSetUID(t *testing.T) {
+	all := CapSet(1<<(CAPLastCap+1) - 1)
+	root := IDs{}
+	user := IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+	full := Capabilities{Effective: all, Permitted: all, Bounding: all, Ambient: NewCapSet(CAPKill)}
+
+	toCheck := []struct {
+		name     string
+		caps     Capabilities
+		bits     Securebits
+		old      IDs
+		next     IDs
+		expected Capabilities
+	}{
+		{
+			name:     "root to user",
+			caps:     full,
+			old:      root,
+			next:     user,
+			expected: Capabilities{Bounding: all},
+		},
+		{
+			name:     "root to user with keep caps",
+			caps:     full,
+			bits:     SecureKeepCaps,
+			old:      root,
+			next:     user,
+			expected: Capabilities{Permitted: all, Bounding: all},
+		},
+		{
+			name:     "root to user without fixup",
+			caps:     full,
+			bits:     SecureNoSetUIDFixup,
+			old:      root,
+			next:     user,
+			expected: full,
+		},
+		{
+			name:     "effective uid only",
+			caps:     full,
+			old:      root,
+			next:     IDs{Real: 0, Effective: 1000, Saved: 0, FS: 1000},
+			expected: Capabilities{Permitted: all, Bounding: all, Ambient: NewCapSet(CAPKill)},
+		},
+		{
+			name:     "back to effective root",
+			caps:     Capabilities{Permitted: all, Bounding: all},
+			old:      IDs{Real: 0, Effective: 1000, Saved: 0, FS: 1000},
+			next:     root,
+			expected: Capabilities{Effective: all, Permitted: all, Bounding: all},
+		},
+		{
+			name: "fsuid away from root",
+			caps: full,
+			old:  root,
+			next: IDs{FS: 1000},
+			expected: Capabilities{
+				Effective: all.Difference(fsCapabilities), Permitted: all,
+				Bounding: all, Ambient: NewCapSet(CAPKill),
+			},
+		},
+	}
+
+	for _, check := range toCheck {
+		caps, err := SimulateSetUID(check.caps, check.bits, check.old, check.next)
+		if err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if caps != check.expected {
+			t.Errorf("'%s' expected %+v got %+v", check.name, check.expected, caps)
+		}
+	}
+
+	_, err := SimulateSetUID(Capabilities{}, 0, user, root)
+	if !errors.Is(err, ErrChangingUIDFailed) {
+		t.Errorf("Expected %s, got %v", ErrChangingUIDFailed, err)
+	}
+}
+
+func TestSimulateSetUIDDropSequence(t *testing.T) {
+	all := CapSet(1<<(CAPLastCap+1) - 1)
+	netBind := NewCapSet(CAPNetBindService)
+	user := IDs{Real: 1000, Effective: 1000, Saved: 1000, FS: 1000}
+
+	// keep caps, change uid, then reduce to the single capability needed
+	caps := Capabilities{Effective: all, Permitted: all, Bounding: all}
+	caps, err := SimulateSetUID(caps, SecureKeepCaps, IDs{}, user)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	next := Capabilities{Effective: netBind, Permitted: netBind, Bounding: all}
+	if !capsetAllowed(caps, next) {
+		t.Fatalf("Expected capset of %+v to be allowed from %+v", next, caps)
+	}
+
+	caps = capset(caps, next)
+	if _, err := SimulateSetUID(caps, 0, user, IDs{}); !errors.Is(err, ErrChangingUIDFailed) {
+		t.Errorf("Expected regaining root to fail, got %v", err)
+	}
+}
@@ -1,5 +1,11 @@
 package gocapng
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Act enum to update the stored capabilities settings
 type Act int
 
@@ -22,6 +28,24 @@ type Flags int
 // variable that hold them under
 type Capability uint
 
+// Securebits is the bitmap of the process securebits (see PR_SET_SECUREBITS)
+type Securebits int
+
+// IDs holds the real, effective, saved and filesystem ids of a process, either
+// for user ids or for group ids
+type IDs struct {
+	Real      int
+	Effective int
+	Saved     int
+	FS        int
+}
+
+// RiskTier classifies how dangerous it is to grant a capability
+type RiskTier int
+
+// Format is a layout of WriteCapabilities
+type Format int
+
 // UserCapData holds libcap user data
 type UserCapData struct {
 	Effective   uint32
@@ -116,3 +140,123 @@ func (f Flags) String() string {
 		return ""
 	}
 }
+
+func (s Securebits) String() string {
+	if s == 0 {
+		return "none"
+	}
+
+	names := []string{}
+	for bit := SecureNoRoot; bit <= SecureNoCapAmbientRaiseLocked; bit <<= 1 {
+		if s&bit == 0 {
+			continue
+		}
+		switch bit {
+		case SecureNoRoot:
+			names = append(names, "noroot")
+		case SecureNoRootLocked:
+			names = append(names, "noroot_locked")
+		case SecureNoSetUIDFixup:
+			names = append(names, "no_setuid_fixup")
+		case SecureNoSetUIDFixupLocked:
+			names = append(names, "no_setuid_fixup_locked")
+		case SecureKeepCaps:
+			names = append(names, "keep_caps")
+		case SecureKeepCapsLocked:
+			names = append(names, "keep_caps_locked")
+		case SecureNoCapAmbientRaise:
+			names = append(names, "no_cap_ambient_raise")
+		case SecureNoCapAmbientRaiseLocked:
+			names = append(names, "no_cap_ambient_raise_locked")
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (r RiskTier) String() string {
+	switch r {
+	case RiskUnknown:
+		return "unknown"
+	case RiskLow:
+		return "low"
+	case RiskMedium:
+		return "medium"
+	case RiskHigh:
+		return "high"
+	case RiskCritical:
+		return "critical"
+	default:
+		return ""
+	}
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatNumeric:
+		return "numeric"
+	case FormatJSON:
+		return "json"
+	case FormatTable:
+		return "table"
+	default:
+		return ""
+	}
+}
+
+func (ids IDs) String() string {
+	return fmt.Sprintf("%d,%d,%d,%d", ids.Real, ids.Effective, ids.Saved, ids.FS)
+}
+
+// String returns the capability name, or a synthetic "cap_<n>" name for
+// capabilities this package does not know about
+func (c Capability) String() string {
+	if int(c) < len(capabilityNames) {
+		return capabilityNames[c]
+	}
+	return "cap_" + strconv.FormatUint(uint64(c), 10)
+}
+
+// ParseCapability returns the capability named name. Names are accepted with
+// or without the "cap_" prefix, in any case ("net_raw", "CAP_NET_RAW"), as well
+// as the synthetic "cap_<n>" names returned by String.
+//
+// Unlike NameToCapability, ParseCapability does not need libcap-ng.
+func ParseCapability(name string) (Capability, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	for c, capabilityName := range capabilityNames {
+		if lower == capabilityName || lower == "cap_"+capabilityName {
+			return Capability(c), nil
+		}
+	}
+
+	if n, err := strconv.ParseUint(strings.TrimPrefix(lower, "cap_"), 10, 6); err == nil {
+		return Capability(n), nil
+	}
+	return 0, ErrCapabilityNotFound
+}
+
+// ParseSecurebits returns the securebits named in names, using the names of
+// Securebits.String
+func ParseSecurebits(names ...string) (Securebits, error) {
+	var bits Securebits
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		found := false
+		for bit := SecureNoRoot; bit <= SecureNoCapAmbientRaiseLocked; bit <<= 1 {
+			if bit.String() == name {
+				bits |= bit
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownSecurebit, name)
+		}
+	}
+	return bits, nil
+}
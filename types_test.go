@@ -251,3 +251,34 @@ func TestFlagsString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCapability(t *testing.T) {
+	toCheck := []struct {
+		name     string
+		expected Capability
+		err      bool
+	}{
+		{name: "net_raw", expected: CAPNetRaw},
+		{name: "CAP_NET_RAW", expected: CAPNetRaw},
+		{name: "cap_sys_admin", expected: CAPSysAdmin},
+		{name: "cap_62", expected: Capability(62)},
+		{name: "12", expected: CAPNetAdmin},
+		{name: "fly", err: true},
+		{name: "cap_64", err: true},
+	}
+
+	for _, check := range toCheck {
+		capability, err := ParseCapability(check.name)
+		if (err != nil) != check.err || capability != check.expected {
+			t.Errorf(
+				"'%s' expected to be '%s' but have '%s' (%v) instead",
+				check.name, check.expected, capability, err,
+			)
+		}
+	}
+
+	bits, err := ParseSecurebits("noroot", "keep_caps_locked")
+	if err != nil || bits != SecureNoRoot|SecureKeepCapsLocked {
+		t.Errorf("Expected noroot,keep_caps_locked, got %s (%v)", bits, err)
+	}
+}
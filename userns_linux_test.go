@@ -0,0 +1,203 @@
+//go:build linux && cgo
+
+package gocapng
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// usernsChildEnv holds the name of the test a re-executed test binary runs
+// inside of a user namespace
+const usernsChildEnv = "GOCAPNG_USERNS_TEST"
+
+// RunWithCaps runs fn in a copy of the test binary executed inside of a new
+// user namespace, where the test user is mapped to root, with exactly caps in
+// the effective and permitted sets of the thread running fn. Capability
+// changes are real but confined to the namespace, so Apply, ChangeID, Lock and
+// file capabilities writes can be tested without being root.
+//
+// fn runs on a thread of its own, and must not expect goroutines it starts to
+// hold the same capabilities. The test is skipped when user namespaces are
+// not available.
+func RunWithCaps(t *testing.T, caps CapSet, fn func(t *testing.T)) {
+	t.Helper()
+
+	if os.Getenv(usernsChildEnv) == t.Name() {
+		// the thread is never given back to the runtime, as its
+		// capabilities differ from the other threads
+		runtime.LockOSThread()
+
+		cp := Init()
+		cp.Clear(SelectCaps)
+		for _, capability := range caps.List() {
+			cp.Update(ActAdd, TypeEffective|TypePermitted, capability)
+		}
+		if err := cp.Apply(SelectCaps); err != nil {
+			t.Fatalf("Unable to apply %s in the user namespace: %s", caps, err)
+		}
+
+		fn(t)
+		return
+	}
+
+	skipUnlessUserNamespaces(t)
+
+	cmd := exec.Command(os.Args[0], "-test.run="+testRunPattern(t.Name()), "-test.v")
+	cmd.Env = append(os.Environ(), usernsChildEnv+"="+t.Name())
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+	}
+
+	output, err := cmd.CombinedOutput()
+	var errno syscall.Errno
+	if errors.As(err, &errno) && (errno == syscall.EPERM || errno == syscall.EINVAL || errno == syscall.ENOSPC) {
+		t.Skipf("Unable to create a user namespace: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Test failed in the user namespace: %s\n%s", err, output)
+	}
+	if strings.Contains(string(output), "--- SKIP") {
+		t.Skipf("Test skipped in the user namespace:\n%s", output)
+	}
+}
+
+// testRunPattern returns the -test.run pattern matching exactly the test or
+// subtest name
+func testRunPattern(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+	return strings.Join(parts, "/")
+}
+
+// skipUnlessUserNamespaces skips the test when the kernel does not allow
+// creating user namespaces
+func skipUnlessUserNamespaces(t *testing.T) {
+	t.Helper()
+
+	if _, err := os.Stat("/proc/self/ns/user"); err != nil {
+		t.Skip("User namespaces are not supported")
+	}
+	for _, path := range []string{
+		"/proc/sys/user/max_user_namespaces",
+		"/proc/sys/kernel/unprivileged_userns_clone",
+	} {
+		if value, err := readSysctlInt(path); err == nil && value == 0 {
+			t.Skipf("User namespaces are disabled by %s", path)
+		}
+	}
+}
+
+func TestUserNSApply(t *testing.T) {
+	RunWithCaps(t, NewCapSet(CAPNetBindService, CAPNetRaw), func(t *testing.T) {
+		state, err := readThreadState()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if state.Caps.Effective != NewCapSet(CAPNetBindService, CAPNetRaw) {
+			t.Fatalf("Expected net_bind_service,net_raw, got %s", state.Caps.Effective)
+		}
+
+		cp := Init()
+		cp.Clear(SelectCaps)
+		cp.Update(ActAdd, TypeEffective|TypePermitted, CAPNetBindService)
+		if err := cp.Apply(SelectCaps); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		state, err = readThreadState()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if state.Caps.Permitted != NewCapSet(CAPNetBindService) {
+			t.Errorf("Expected net_bind_service, got %s", state.Caps.Permitted)
+		}
+
+		// dropped capabilities cannot come back
+		cp.Update(ActAdd, TypeEffective|TypePermitted, CAPNetRaw)
+		if err := cp.Apply(SelectCaps); !errors.Is(err, ErrSelectCapsCapsetSyscall) {
+			t.Errorf("Expected %s, got %v", ErrSelectCapsCapsetSyscall, err)
+		}
+	})
+}
+
+func TestUserNSChangeID(t *testing.T) {
+	RunWithCaps(t, NewCapSet(CAPSetPCap, CAPNetRaw), func(t *testing.T) {
+		cp := Init()
+		cp.Clear(SelectCaps)
+		cp.Update(ActAdd, TypeEffective|TypePermitted, CAPNetRaw)
+		if err := cp.ChangeID(-1, -1, FlagsClearBounding); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		state, err := readThreadState()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if state.Caps.Bounding != 0 {
+			t.Errorf("Expected an empty bounding set, got %s", state.Caps.Bounding)
+		}
+		if state.Caps.Permitted != NewCapSet(CAPNetRaw) {
+			t.Errorf("Expected net_raw, got %s", state.Caps.Permitted)
+		}
+	})
+}
+
+func TestUserNSLock(t *testing.T) {
+	RunWithCaps(t, NewCapSet(CAPSetPCap), func(t *testing.T) {
+		if !Init().Lock() {
+			t.Fatal("Expected Lock to succeed")
+		}
+
+		state, err := readThreadState()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		locked := SecureNoRoot | SecureNoRootLocked | SecureNoSetUIDFixup | SecureNoSetUIDFixupLocked
+		if state.Securebits&locked != locked {
+			t.Errorf("Expected %s, got %s", locked, state.Securebits)
+		}
+	})
+}
+
+func TestUserNSFileCaps(t *testing.T) {
+	RunWithCaps(t, NewCapSet(CAPSetFCap), func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "filecaps")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		cp := Init()
+		cp.Clear(SelectAll)
+		cp.Update(ActAdd, TypeEffective|TypePermitted, CAPNetRaw)
+		if err := cp.ApplyCapsFD(*f); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		caps, err := ReadFileCaps(f.Name())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if caps.Version == 0 {
+			t.Skip("The file system does not support file capabilities")
+		}
+		if caps.Permitted != NewCapSet(CAPNetRaw) || !caps.Effective {
+			t.Errorf("Expected net_raw+ep, got %+v", caps)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+//go:build linux && cgo
+
+package gocapng
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCapsMatchesPrintCaps(t *testing.T) {
+	cp := Init()
+	cp.Clear(SelectAll)
+	cp.Updatev(ActAdd, TypeEffective|TypePermitted, CAPKill)
+	cp.Update(ActAdd, TypeBoundingSet, CAPBPF)
+
+	var b bytes.Buffer
+	if err := cp.WriteCaps(&b, SelectAll, FormatNumeric); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := cp.PrintCapsNumberic(PrintBuffer, SelectAll); b.String() != expected {
+		t.Errorf("Expected\n%s\nbut have\n%s\ninstead", expected, b.String())
+	}
+
+	caps, err := ParseCapsNumeric(&b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if caps != cp.pending() {
+		t.Errorf("Expected %+v but have %+v instead", cp.pending(), caps)
+	}
+}
@@ -0,0 +1,187 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// setLabels holds the labels libcap-ng prints for every set
+var setLabels = map[Type]string{
+	TypeEffective:   "Effective",
+	TypePermitted:   "Permitted",
+	TypeInheritable: "Inheritable",
+	TypeBoundingSet: "Bounding Set",
+	TypeAmbient:     "Ambient Set",
+}
+
+// setKeys holds the JSON keys of every set, matching the policy files
+var setKeys = map[Type]string{
+	TypeEffective:   "effective",
+	TypePermitted:   "permitted",
+	TypeInheritable: "inheritable",
+	TypeBoundingSet: "bounding",
+	TypeAmbient:     "ambient",
+}
+
+// typesOf returns the sets of set, in the order libcap-ng prints them
+func typesOf(set Select) []Type {
+	selected := selectTypes(set)
+	var result []Type
+	for _, t := range allTypes {
+		if selected&t != 0 {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// WriteCapabilities writes the sets of set held by caps to w in the given
+// layout:
+//
+//	FormatText:    Effective: kill, net_raw
+//	FormatNumeric: Effective:   00000000, 00002020
+//	FormatJSON:    {"effective":["kill","net_raw"]}
+//	FormatTable:   CAPABILITY  EFFECTIVE
+//	               kill        yes
+//
+// Empty sets are written as "none" by FormatText, as an empty list by
+// FormatJSON, and have no row in FormatTable. FormatNumeric is the layout of
+// libcap-ng, read back by ParseCapsNumeric.
+func WriteCapabilities(w io.Writer, caps Capabilities, set Select, format Format) error {
+	types := typesOf(set)
+
+	switch format {
+	case FormatText:
+		for _, t := range types {
+			if _, err := fmt.Fprintf(w, "%s: %s\n", setLabels[t], capsText(caps.Get(t))); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatNumeric:
+		for _, t := range types {
+			value := uint64(caps.Get(t))
+			_, err := fmt.Fprintf(w, "%-12s %08X, %08X\n", setLabels[t]+":", uint32(value>>32), uint32(value))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatJSON:
+		object := make(map[string][]string, len(types))
+		for _, t := range types {
+			names := []string{}
+			for _, capability := range caps.Get(t).List() {
+				names = append(names, capability.String())
+			}
+			object[setKeys[t]] = names
+		}
+		return json.NewEncoder(w).Encode(object)
+
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := []string{"CAPABILITY"}
+		var all CapSet
+		for _, t := range types {
+			header = append(header, strings.ToUpper(setKeys[t]))
+			all = all.Union(caps.Get(t))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, capability := range all.List() {
+			row := []string{capability.String()}
+			for _, t := range types {
+				cell := "-"
+				if caps.Get(t).Has(capability) {
+					cell = "yes"
+				}
+				row = append(row, cell)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("%w: %d", ErrUnknownFormat, format)
+}
+
+// capsText returns the names of set in the layout of PrintCapsText
+func capsText(set CapSet) string {
+	if set.IsEmpty() {
+		return "none"
+	}
+
+	list := set.List()
+	names := make([]string, 0, len(list))
+	for _, capability := range list {
+		names = append(names, capability.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseCapsNumeric reads the output of PrintCapsNumberic, of the numeric
+// layout of WriteCapabilities, or of capng_print_caps_numeric in other
+// programs, back into Capabilities.
+//
+// Sets that are not part of the output are left empty. Both the two words
+// layout and the single word layout of version 1 capabilities are accepted.
+func ParseCapsNumeric(r io.Reader) (Capabilities, error) {
+	var caps Capabilities
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		idx := strings.IndexByte(line, ':')
+		if idx < 0 {
+			return Capabilities{}, fmt.Errorf("%w: %q", ErrInvalidNumericCaps, line)
+		}
+		label, value := line[:idx], line[idx+1:]
+
+		t, ok := numericLabelType(label)
+		if !ok {
+			return Capabilities{}, fmt.Errorf("%w: unknown set %q", ErrInvalidNumericCaps, label)
+		}
+
+		var set uint64
+		words := strings.Split(value, ",")
+		if len(words) > 2 {
+			return Capabilities{}, fmt.Errorf("%w: %q", ErrInvalidNumericCaps, line)
+		}
+		for _, word := range words {
+			parsed, err := strconv.ParseUint(strings.TrimSpace(word), 16, 32)
+			if err != nil {
+				return Capabilities{}, fmt.Errorf("%w: %s: %s", ErrInvalidNumericCaps, label, err)
+			}
+			set = set<<32 | parsed
+		}
+		caps.Set(t, CapSet(set))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Capabilities{}, err
+	}
+
+	return caps, nil
+}
+
+// numericLabelType returns the set printed with label
+func numericLabelType(label string) (Type, bool) {
+	for t, known := range setLabels {
+		if label == known {
+			return t, true
+		}
+	}
+	return 0, false
+}
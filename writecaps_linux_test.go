@@ -0,0 +1,125 @@
+//go:build linux
+
+package gocapng
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteCapabilities(t *testing.T) {
+	caps := Capabilities{
+		Effective: NewCapSet(CAPKill, CAPNetRaw),
+		Permitted: NewCapSet(CAPKill, CAPNetRaw, CAPBPF),
+		Bounding:  NewCapSet(CAPKill, CAPNetRaw, CAPBPF),
+	}
+
+	type toCheck struct {
+		name     string
+		set      Select
+		format   Format
+		expected string
+	}
+
+	checks := []toCheck{
+		{
+			name:   "text",
+			set:    SelectCaps,
+			format: FormatText,
+			expected: "Effective: kill, net_raw\n" +
+				"Permitted: kill, net_raw, bpf\n" +
+				"Inheritable: none\n",
+		},
+		{
+			name:   "numeric",
+			set:    SelectAll,
+			format: FormatNumeric,
+			expected: "Effective:   00000000, 00002020\n" +
+				"Permitted:   00000080, 00002020\n" +
+				"Inheritable: 00000000, 00000000\n" +
+				"Bounding Set: 00000080, 00002020\n" +
+				"Ambient Set: 00000000, 00000000\n",
+		},
+		{
+			name:     "json",
+			set:      SelectBounds | SelectAmbient,
+			format:   FormatJSON,
+			expected: `{"ambient":[],"bounding":["kill","net_raw","bpf"]}` + "\n",
+		},
+		{
+			name:   "table",
+			set:    SelectBoth,
+			format: FormatTable,
+			expected: "CAPABILITY  EFFECTIVE  PERMITTED  INHERITABLE  BOUNDING\n" +
+				"kill        yes        yes        -            yes\n" +
+				"net_raw     yes        yes        -            yes\n" +
+				"bpf         -          yes        -            yes\n",
+		},
+	}
+
+	for _, check := range checks {
+		var b bytes.Buffer
+		if err := WriteCapabilities(&b, caps, check.set, check.format); err != nil {
+			t.Errorf("'%s' unexpected error: %s", check.name, err)
+			continue
+		}
+		if b.String() != check.expected {
+			t.Errorf("'%s' expected\n%s\nbut have\n%s\ninstead", check.name, check.expected, b.String())
+		}
+	}
+
+	err := WriteCapabilities(&bytes.Buffer{}, caps, SelectAll, Format(42))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected %s, got %v", ErrUnknownFormat, err)
+	}
+}
+
+func TestParseCapsNumeric(t *testing.T) {
+	// output of capng_print_caps_numeric(CAPNG_PRINT_BUFFER, CAPNG_SELECT_ALL)
+	output := "Effective:   000001FF, FEFFFFFF\n" +
+		"Permitted:   000001FF, FEFFFFFF\n" +
+		"Inheritable: 00000000, 00000000\n" +
+		"Bounding Set: 000001FF, FEFFFFFF\n" +
+		"Ambient Set: 00000000, 00002000\n"
+
+	caps, err := ParseCapsNumeric(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := Capabilities{
+		Effective: 0x1fffeffffff,
+		Permitted: 0x1fffeffffff,
+		Bounding:  0x1fffeffffff,
+		Ambient:   NewCapSet(CAPNetRaw),
+	}
+	if caps != expected {
+		t.Errorf("Expected %+v but have %+v instead", expected, caps)
+	}
+
+	var b bytes.Buffer
+	if err := WriteCapabilities(&b, caps, SelectAll, FormatNumeric); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if b.String() != output {
+		t.Errorf("Expected the output to round trip, have\n%s\ninstead", b.String())
+	}
+
+	caps, err = ParseCapsNumeric(strings.NewReader("Effective:   00002000\n"))
+	if err != nil || caps.Effective != NewCapSet(CAPNetRaw) {
+		t.Errorf("Expected a version 1 set to be parsed, have %s (%v) instead", caps.Effective, err)
+	}
+
+	for _, invalid := range []string{
+		"Effective 00000000, 00000000\n",
+		"Unknown: 00000000, 00000000\n",
+		"Effective: 0, 0, 0\n",
+		"Effective: 00000000, zzzzzzzz\n",
+	} {
+		if _, err := ParseCapsNumeric(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidNumericCaps) {
+			t.Errorf("'%s' expected %s but have %v instead", invalid, ErrInvalidNumericCaps, err)
+		}
+	}
+}